@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/budget"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/export"
+	"frederikhs/wolt/storage"
+	"os"
+	"time"
+)
+
+// exitBudgetExceeded is the exit code of the budget command when a budget
+// is exceeded, so it can be told apart from failing, which exits with 2 like
+// every panic.
+const exitBudgetExceeded = 3
+
+func Budget(args []string) error {
+	fs := flag.NewFlagSet("budget", flag.ExitOnError)
+	configPath := fs.String("config", config.DefaultPath, "config file with the budgets")
+	months := fs.Int("months", 6, "number of past months to show")
+	projected := fs.Bool("projected", false, "also fail when the projected spend exceeds the budget")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fmt.Printf("usage: %s budget [-config wolt.yaml] [-months 6] [-projected]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	c, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if c.Budget.Monthly == 0 && c.Budget.Weekly == 0 {
+		return fmt.Errorf("no budget set in %s", *configPath)
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	now := time.Now()
+
+	if c.Budget.Monthly > 0 && *months > 0 {
+		from := budget.MonthStart(now).AddDate(0, -*months, 0)
+		to := budget.MonthStart(now)
+
+		history, err := storage.GetSpendByMonth(db, storage.Filter{From: &from, To: &to})
+		if err != nil {
+			return err
+		}
+
+		for _, p := range *history {
+			fmt.Printf("%-10s %10s of %10s\n", p.Period, export.Money(p.Spend), export.Money(budget.MinorUnits(c.Budget.Monthly)))
+		}
+	}
+
+	periods, err := budget.Current(db, c.Budget, storage.Filter{}, now)
+	if err != nil {
+		return err
+	}
+
+	exceeded := false
+	for _, p := range periods {
+		state := "ok"
+		switch {
+		case p.Exceeded():
+			state = "exceeded"
+			exceeded = true
+		case p.ProjectedExceeded():
+			state = "projected to exceed"
+			exceeded = exceeded || *projected
+		}
+
+		fmt.Printf("%-10s %10s of %10s (%d%%), projected %s, %s\n",
+			p.Name, export.Money(p.Spend), export.Money(p.Budget), p.Share(), export.Money(p.Projected), state)
+	}
+
+	if exceeded {
+		db.Close()
+		os.Exit(exitBudgetExceeded)
+	}
+
+	return nil
+}
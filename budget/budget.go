@@ -0,0 +1,118 @@
+package budget
+
+import (
+	"fmt"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/storage"
+	"github.com/jmoiron/sqlx"
+	"math"
+	"time"
+)
+
+// Period is the spend within a budget period so far. Amounts are in minor
+// units.
+type Period struct {
+	Name      string
+	Start     time.Time
+	End       time.Time
+	Spend     int
+	Budget    int
+	Projected int
+}
+
+func (p Period) Exceeded() bool {
+	return p.Budget > 0 && p.Spend > p.Budget
+}
+
+func (p Period) ProjectedExceeded() bool {
+	return p.Budget > 0 && p.Projected > p.Budget
+}
+
+// Share is the spend as a percentage of the budget, 0 when the budget rounds
+// to nothing.
+func (p Period) Share() int {
+	if p.Budget <= 0 {
+		return 0
+	}
+
+	return p.Spend * 100 / p.Budget
+}
+
+func MinorUnits(amount float64) int {
+	return int(math.Round(amount * 100))
+}
+
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// WeekStart returns the start of the monday of the week of t.
+func WeekStart(t time.Time) time.Time {
+	days := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, t.Location())
+}
+
+// Current returns the month and week containing now that have a budget,
+// with the spend so far projected to the end of the period at the current
+// run rate.
+func Current(db *sqlx.DB, c config.Budget, f storage.Filter, now time.Time) ([]Period, error) {
+	var periods []Period
+
+	if c.Monthly > 0 {
+		start := MonthStart(now)
+		periods = append(periods, Period{
+			Name:   start.Format("2006-01"),
+			Start:  start,
+			End:    start.AddDate(0, 1, 0),
+			Budget: MinorUnits(c.Monthly),
+		})
+	}
+
+	if c.Weekly > 0 {
+		start := WeekStart(now)
+		periods = append(periods, Period{
+			Name:   WeekName(start),
+			Start:  start,
+			End:    start.AddDate(0, 0, 7),
+			Budget: MinorUnits(c.Weekly),
+		})
+	}
+
+	for i := range periods {
+		p := &periods[i]
+
+		pf := f
+		pf.From, pf.To = &p.Start, &p.End
+
+		spend, err := storage.GetTotalSpend(db, pf)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Spend = spend
+		p.Projected = Project(spend, p.Start, p.End, now)
+	}
+
+	return periods, nil
+}
+
+// Project extrapolates the spend between start and now to the whole period
+// ending at end.
+func Project(spend int, start, end, now time.Time) int {
+	elapsed := now.Sub(start)
+	if elapsed <= 0 {
+		return spend
+	}
+
+	if now.After(end) {
+		return spend
+	}
+
+	return int(math.Round(float64(spend) * float64(end.Sub(start)) / float64(elapsed)))
+}
+
+// WeekName names the week starting on the monday start the way sqlite
+// numbers weeks with %W, where the days before the first monday are week 00.
+func WeekName(start time.Time) string {
+	return fmt.Sprintf("%s-W%02d", start.Format("2006"), (start.YearDay()+6)/7)
+}
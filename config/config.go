@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"regexp"
+)
+
+const DefaultPath = "wolt.yaml"
+
+type Config struct {
+	Accounts []Account `yaml:"accounts"`
+	Ledger   Ledger    `yaml:"ledger"`
+	Expense  Expense   `yaml:"expense"`
+	Budget   Budget    `yaml:"budget"`
+	Notify   Notify    `yaml:"notify"`
+}
+
+// Account is a Wolt account synced by sync, its token is either given
+// directly or read from the environment variable TokenEnv.
+type Account struct {
+	Name     string `yaml:"name"`
+	Token    string `yaml:"token"`
+	TokenEnv string `yaml:"token_env"`
+}
+
+var accountName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// GetToken returns the token of the account.
+func (a Account) GetToken() (string, error) {
+	if a.TokenEnv != "" {
+		token := os.Getenv(a.TokenEnv)
+		if token == "" {
+			return "", fmt.Errorf("account %s: environment variable %s is empty", a.Name, a.TokenEnv)
+		}
+
+		return token, nil
+	}
+
+	if a.Token == "" {
+		return "", fmt.Errorf("account %s: no token or token_env configured", a.Name)
+	}
+
+	return a.Token, nil
+}
+
+// FindAccount returns the configured account with the name.
+func (c *Config) FindAccount(name string) (Account, error) {
+	for _, a := range c.Accounts {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+
+	return Account{}, fmt.Errorf("account %s is not configured", name)
+}
+
+// Notify configures where a sync reports new orders and the budgets, each
+// notifier is enabled by setting it.
+type Notify struct {
+	Webhook *Webhook `yaml:"webhook"`
+	Smtp    *Smtp    `yaml:"smtp"`
+}
+
+type Webhook struct {
+	Url     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+type Smtp struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// Budget holds spending limits in major units, 0 meaning no budget.
+type Budget struct {
+	Monthly float64 `yaml:"monthly"`
+	Weekly  float64 `yaml:"weekly"`
+}
+
+type Expense struct {
+	// VatRate is the vat percentage included in prices, shown separately on
+	// expense reports when set.
+	VatRate float64 `yaml:"vat_rate"`
+}
+
+func Default() *Config {
+	return &Config{
+		Ledger: Ledger{
+			Accounts: LedgerAccounts{
+				Food:        "Expenses:Food:Wolt",
+				Delivery:    "Expenses:Food:Wolt:Delivery",
+				ServiceFee:  "Expenses:Food:Wolt:ServiceFee",
+				Tip:         "Expenses:Food:Wolt:Tip",
+				Adjustments: "Income:Wolt:Adjustments",
+				Payment:     "Liabilities:Wolt",
+			},
+		},
+	}
+}
+
+// Load reads the config at path on top of the defaults. A missing file at
+// the default path is not an error, everything is then left at its default.
+func Load(path string) (*Config, error) {
+	c := Default()
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && path == DefaultPath {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(b, c)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, a := range c.Accounts {
+		if !accountName.MatchString(a.Name) {
+			return nil, fmt.Errorf("account name %q may only contain letters, digits, _ and -", a.Name)
+		}
+		if seen[a.Name] {
+			return nil, fmt.Errorf("account %s is configured twice", a.Name)
+		}
+		seen[a.Name] = true
+	}
+
+	return c, nil
+}
@@ -0,0 +1,44 @@
+package config
+
+type Ledger struct {
+	Accounts LedgerAccounts   `yaml:"accounts"`
+	Payments []PaymentAccount `yaml:"payments"`
+}
+
+type LedgerAccounts struct {
+	Food        string `yaml:"food"`
+	Delivery    string `yaml:"delivery"`
+	ServiceFee  string `yaml:"service_fee"`
+	Tip         string `yaml:"tip"`
+	Adjustments string `yaml:"adjustments"`
+	Payment     string `yaml:"payment"`
+}
+
+// PaymentAccount maps the payment method of an order to the account it was
+// paid from. Empty fields match anything.
+type PaymentAccount struct {
+	Provider string `yaml:"provider"`
+	Type     string `yaml:"type"`
+	Id       string `yaml:"id"`
+	Name     string `yaml:"name"`
+	Account  string `yaml:"account"`
+}
+
+func (pa PaymentAccount) matches(provider, paymentType, id, name string) bool {
+	return (pa.Provider == "" || pa.Provider == provider) &&
+		(pa.Type == "" || pa.Type == paymentType) &&
+		(pa.Id == "" || pa.Id == id) &&
+		(pa.Name == "" || pa.Name == name)
+}
+
+// PaymentAccount returns the account of the first matching payment mapping,
+// or the default payment account when none match.
+func (l Ledger) PaymentAccount(provider, paymentType, id, name string) string {
+	for _, pa := range l.Payments {
+		if pa.matches(provider, paymentType, id, name) {
+			return pa.Account
+		}
+	}
+
+	return l.Accounts.Payment
+}
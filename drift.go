@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"frederikhs/wolt/storage"
+	"frederikhs/wolt/wolt"
+	"os"
+)
+
+// Drift checks the orders archived as the api sent them, or the orders of a
+// json file, against FullOrder and prints what it is missing or gets wrong.
+func Drift(args []string) error {
+	fs := flag.NewFlagSet("drift", flag.ExitOnError)
+	file := fs.String("file", "", "check a json array of orders as the api returns them instead of the raw order archive")
+	asJson := fs.Bool("json", false, "print the drift report as json")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fmt.Printf("usage: %s drift [-file response.json] [-json]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	orders, err := driftOrders(*file)
+	if err != nil {
+		return err
+	}
+
+	report, err := wolt.CheckDrift(orders)
+	if err != nil {
+		return err
+	}
+
+	if *asJson {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	fmt.Printf("checked %d orders against wolt.FullOrder\n", report.Orders)
+	if len(report.Drifts) == 0 {
+		fmt.Println("no drift found")
+		return nil
+	}
+
+	fmt.Println()
+	for _, d := range report.Drifts {
+		var types string
+		switch d.Kind {
+		case wolt.DriftUnknown:
+			types = d.Got
+		case wolt.DriftMissing:
+			types = d.Expected
+		default:
+			types = fmt.Sprintf("%s, got %s", d.Expected, d.Got)
+		}
+
+		fmt.Printf("%-9s %-45s %-32s in %d of %d orders, e.g. %s\n", d.Kind, d.Path, types, d.Orders, report.Orders, d.Example)
+	}
+
+	return nil
+}
+
+func driftOrders(file string) ([]json.RawMessage, error) {
+	var orders []json.RawMessage
+
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(b, &orders)
+		return orders, err
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	raw, err := storage.GetRawOrders(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Orders archived from the orders json were re-marshalled from FullOrder
+	// and cannot drift from it.
+	for _, r := range *raw {
+		if r.Source == storage.RawSourceApi {
+			orders = append(orders, r.Data)
+		}
+	}
+
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("no orders archived from the api, run sync first or check a file with -file")
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/export"
+	"frederikhs/wolt/report"
+	"frederikhs/wolt/storage"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+func expenseUsage() {
+	fmt.Printf("usage: %s expense <command> [arguments]\n\n", os.Args[0])
+	fmt.Println("commands:")
+	fmt.Println("  tag [-order ID] [-venue NAME] [-alias ALIAS] [-from DATE] [-to DATE]    tag matching orders as business")
+	fmt.Println("  untag <RULE_ID>                                                       remove a tagging rule")
+	fmt.Println("  rules                                                                 list the tagging rules")
+	fmt.Println("  report [-month 2006-01] [-out .] [-config wolt.yaml]                  write monthly expense reports")
+	os.Exit(1)
+}
+
+func Expense(args []string) error {
+	if len(args) < 1 {
+		expenseUsage()
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "tag":
+		fs := flag.NewFlagSet("expense tag", flag.ExitOnError)
+		orderId := fs.String("order", "", "order id")
+		venue := fs.String("venue", "", "venue name or id")
+		alias := fs.String("alias", "", "delivery address alias")
+		from := fs.String("from", "", "first date, inclusive")
+		to := fs.String("to", "", "last date, inclusive")
+		fs.Parse(args[1:])
+
+		f, err := report.DateFilter(*from, *to)
+		if err != nil {
+			return err
+		}
+
+		rule := storage.ExpenseRule{
+			OrderId:       nilIfEmpty(*orderId),
+			Venue:         nilIfEmpty(*venue),
+			DeliveryAlias: nilIfEmpty(*alias),
+			DateFrom:      f.From,
+			DateTo:        f.To,
+		}
+
+		id, err := storage.AddExpenseRule(db, rule)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("added expense rule %d\n", id)
+	case "untag":
+		if len(args) != 2 {
+			expenseUsage()
+		}
+
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		return storage.DeleteExpenseRule(db, id)
+	case "rules":
+		rules, err := storage.GetExpenseRules(db)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range *rules {
+			fmt.Printf("%d\torder=%s\tvenue=%s\talias=%s\tfrom=%s\tto=%s\n",
+				r.RuleId, valueOrAny(r.OrderId), valueOrAny(r.Venue), valueOrAny(r.DeliveryAlias), dateOrAny(r.DateFrom, 0), dateOrAny(r.DateTo, -1))
+		}
+	case "report":
+		fs := flag.NewFlagSet("expense report", flag.ExitOnError)
+		month := fs.String("month", "", "only report this month, as 2006-01")
+		out := fs.String("out", ".", "directory to write the reports to")
+		configPath := fs.String("config", config.DefaultPath, "config file with the vat rate")
+		fs.Parse(args[1:])
+
+		c, err := config.Load(*configPath)
+		if err != nil {
+			return err
+		}
+
+		var f storage.Filter
+		if *month != "" {
+			start, err := time.ParseInLocation("2006-01", *month, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid month: %w", err)
+			}
+			end := start.AddDate(0, 1, 0)
+			f.From, f.To = &start, &end
+		}
+
+		reports, err := export.ExpenseReports(db, f, c.Expense.VatRate)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range reports {
+			err = writeExpenseReport(r, *out)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s: %d orders, %s %s\n", r.Month, len(r.Orders), export.Money(r.Total), r.Currency)
+		}
+	default:
+		expenseUsage()
+	}
+
+	return nil
+}
+
+func writeExpenseReport(r export.ExpenseReport, out string) error {
+	csvFile, err := os.Create(filepath.Join(out, "expenses-"+r.Month+".csv"))
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	err = export.WriteExpenseCSV(csvFile, r)
+	if err != nil {
+		return err
+	}
+
+	htmlFile, err := os.Create(filepath.Join(out, "expenses-"+r.Month+".html"))
+	if err != nil {
+		return err
+	}
+	defer htmlFile.Close()
+
+	err = export.WriteExpenseHTML(htmlFile, r)
+	if err != nil {
+		return err
+	}
+
+	err = csvFile.Close()
+	if err != nil {
+		return err
+	}
+
+	return htmlFile.Close()
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func valueOrAny(s *string) string {
+	if s == nil {
+		return "*"
+	}
+
+	return *s
+}
+
+// dateOrAny formats t shifted by days, so exclusive ends print inclusive.
+func dateOrAny(t *time.Time, days int) string {
+	if t == nil {
+		return "*"
+	}
+
+	return t.AddDate(0, 0, days).Format("2006-01-02")
+}
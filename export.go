@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/export"
+	"frederikhs/wolt/storage"
+	"frederikhs/wolt/wolt"
+	"github.com/jmoiron/sqlx"
+	"os"
+	"path/filepath"
+)
+
+func Export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "csv", "export format: csv, ledger, beancount or ics")
+	out := fs.String("out", ".", "directory to write the export to")
+	configPath := fs.String("config", config.DefaultPath, "config file mapping payment methods to ledger accounts")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fmt.Printf("usage: %s export [-format csv|ledger|beancount|ics] [-out .] [-config wolt.yaml]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch *format {
+	case "csv":
+		return export.WriteCSV(db, *out)
+	case "ledger", "beancount":
+		return exportLedger(db, *format, *out, *configPath)
+	case "ics":
+		return exportICS(db, *out)
+	}
+
+	return fmt.Errorf("unknown export format %q", *format)
+}
+
+func exportLedger(db *sqlx.DB, format, out, configPath string) error {
+	c, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+
+	orders, err := storedOrders(db)
+	if err != nil {
+		return err
+	}
+
+	txs := export.Transactions(orders, c.Ledger)
+
+	file, err := os.Create(filepath.Join(out, "wolt."+format))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == "beancount" {
+		err = export.WriteBeancount(file, txs)
+	} else {
+		err = export.WriteLedger(file, txs)
+	}
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+func exportICS(db *sqlx.DB, out string) error {
+	orders, err := storedOrders(db)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filepath.Join(out, "wolt.ics"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = export.WriteICS(file, orders)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// storedOrders returns the orders of every account that sync stores, decoded
+// from the raw order archive and validated the same way.
+func storedOrders(db *sqlx.DB) (*[]wolt.FullOrder, error) {
+	accounts, archived, _, err := archivedOrders(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no orders archived, run sync first")
+	}
+
+	var orders []wolt.FullOrder
+	for _, account := range accounts {
+		o, _ := wolt.Storable(archived[account])
+		orders = append(orders, o...)
+	}
+
+	return &orders, nil
+}
@@ -0,0 +1,269 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"frederikhs/wolt/storage"
+	"github.com/jmoiron/sqlx"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// WriteCSV writes orders.csv, venues.csv, venue_history.csv, items.csv and
+// item_prices.csv to dir. Times are RFC 3339 and money is in major units.
+func WriteCSV(db *sqlx.DB, dir string) error {
+	orders, err := storage.GetSavedOrders(db)
+	if err != nil {
+		return err
+	}
+
+	venues, err := storage.GetSavedVenues(db)
+	if err != nil {
+		return err
+	}
+
+	history, err := storage.GetVenueHistory(db)
+	if err != nil {
+		return err
+	}
+
+	items, err := storage.GetSavedItems(db)
+	if err != nil {
+		return err
+	}
+
+	prices, err := storage.GetSavedItemPrices(db)
+	if err != nil {
+		return err
+	}
+
+	orderRows := [][]string{{
+		"account_id",
+		"order_id",
+		"payment_time",
+		"status",
+		"venue_id",
+		"delivery_method",
+		"delivery_street",
+		"delivery_distance",
+		"delivery_eta",
+		"delivery_time",
+		"preorder_time",
+		"items_price",
+		"delivery_price",
+		"delivery_size_surcharge",
+		"delivery_distance_surcharge",
+		"service_fee",
+		"total_price",
+		"payment_amount",
+		"subscribed",
+		"is_host_paying",
+		"total_price_share",
+		"payment_name",
+	}}
+	for _, o := range *orders {
+		orderRows = append(orderRows, []string{
+			o.AccountId,
+			o.OrderId,
+			Time(o.PaymentTime),
+			o.Status,
+			o.VenueId,
+			o.DeliveryMethod,
+			o.DeliveryStreet,
+			strconv.Itoa(o.DeliveryDistance),
+			Time(o.DeliveryEta),
+			Time(o.DeliveryTime),
+			Time(o.PreorderTime),
+			Money(o.ItemsPrice),
+			Money(o.DeliveryPrice),
+			Money(o.DeliverySizeSurcharge),
+			Money(o.DeliveryDistanceSurcharge),
+			Money(o.ServiceFee),
+			Money(o.TotalPrice),
+			Money(o.PaymentAmount),
+			strconv.FormatBool(o.Subscribed),
+			strconv.FormatBool(o.IsHostPaying),
+			Money(o.TotalPriceShare),
+			o.PaymentName,
+		})
+	}
+
+	venueRows := [][]string{{
+		"venue_id",
+		"venue_name",
+		"venue_address",
+		"venue_full_address",
+		"venue_country",
+		"venue_phone",
+		"venue_timezone",
+		"venue_product_line",
+		"venue_coordinate_x",
+		"venue_coordinate_y",
+		"venue_url",
+	}}
+	for _, v := range *venues {
+		venueRows = append(venueRows, []string{
+			v.VenueId,
+			v.VenueName,
+			v.VenueAddress,
+			v.VenueFullAddress,
+			v.VenueCountry,
+			v.VenuePhone,
+			v.VenueTimezone,
+			v.VenueProductLine,
+			Float(v.VenueCoordinateX),
+			Float(v.VenueCoordinateY),
+			v.VenueUrl,
+		})
+	}
+
+	historyRows := [][]string{{
+		"venue_id",
+		"venue_name",
+		"venue_address",
+		"venue_full_address",
+		"venue_country",
+		"venue_phone",
+		"venue_timezone",
+		"venue_product_line",
+		"venue_coordinate_x",
+		"venue_coordinate_y",
+		"venue_url",
+		"first_seen",
+		"last_seen",
+		"orders",
+	}}
+	for _, v := range *history {
+		historyRows = append(historyRows, []string{
+			v.VenueId,
+			v.VenueName,
+			v.VenueAddress,
+			v.VenueFullAddress,
+			v.VenueCountry,
+			v.VenuePhone,
+			v.VenueTimezone,
+			v.VenueProductLine,
+			Float(v.VenueCoordinateX),
+			Float(v.VenueCoordinateY),
+			v.VenueUrl,
+			Time(v.FirstSeen),
+			Time(v.LastSeen),
+			strconv.Itoa(v.Orders),
+		})
+	}
+
+	itemRows := [][]string{{
+		"account_id",
+		"order_id",
+		"row_number",
+		"item_id",
+		"item_name",
+		"count",
+		"price",
+		"end_amount",
+	}}
+	for _, i := range *items {
+		itemRows = append(itemRows, []string{
+			i.AccountId,
+			i.OrderId,
+			strconv.Itoa(i.RowNumber),
+			i.ItemId,
+			i.ItemName,
+			strconv.Itoa(i.Count),
+			Money(i.Price),
+			Money(i.EndAmount),
+		})
+	}
+
+	priceRows := [][]string{{
+		"venue_id",
+		"venue_name",
+		"item_id",
+		"item_name",
+		"price",
+		"first_seen",
+		"last_seen",
+		"orders",
+	}}
+	for _, p := range *prices {
+		priceRows = append(priceRows, []string{
+			p.VenueId,
+			p.VenueName,
+			p.ItemId,
+			p.ItemName,
+			Money(p.Price),
+			p.FirstSeen,
+			p.LastSeen,
+			strconv.Itoa(p.Orders),
+		})
+	}
+
+	err = writeCSVFile(filepath.Join(dir, "orders.csv"), orderRows)
+	if err != nil {
+		return err
+	}
+
+	err = writeCSVFile(filepath.Join(dir, "venues.csv"), venueRows)
+	if err != nil {
+		return err
+	}
+
+	err = writeCSVFile(filepath.Join(dir, "venue_history.csv"), historyRows)
+	if err != nil {
+		return err
+	}
+
+	err = writeCSVFile(filepath.Join(dir, "items.csv"), itemRows)
+	if err != nil {
+		return err
+	}
+
+	return writeCSVFile(filepath.Join(dir, "item_prices.csv"), priceRows)
+}
+
+func writeCSVFile(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	err = w.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// Money formats an amount in minor units, as the api returns them, in major
+// units with two decimals.
+func Money(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+func Time(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// Float formats f, leaving it empty when it is unknown.
+func Float(f *float64) string {
+	if f == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
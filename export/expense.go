@@ -0,0 +1,179 @@
+package export
+
+import (
+	"encoding/csv"
+	"frederikhs/wolt/storage"
+	"github.com/jmoiron/sqlx"
+	"html/template"
+	"io"
+	"math"
+	"strconv"
+)
+
+type ExpenseLine struct {
+	Description string
+	Count       int
+	Amount      int
+}
+
+type ExpenseOrder struct {
+	storage.BusinessOrder
+	Lines []ExpenseLine
+}
+
+// ExpenseReport holds the business orders of a single month. Amounts are
+// in minor units and include vat.
+type ExpenseReport struct {
+	Month    string
+	Currency string
+	VatRate  float64
+	Orders   []ExpenseOrder
+	Total    int
+}
+
+// Vat returns the vat included in the total of the report.
+func (r ExpenseReport) Vat() int {
+	return int(math.Round(float64(r.Total) * r.VatRate / (100 + r.VatRate)))
+}
+
+// ExpenseReports returns a report per month with business orders matching f.
+func ExpenseReports(db *sqlx.DB, f storage.Filter, vatRate float64) ([]ExpenseReport, error) {
+	orders, err := storage.GetBusinessOrders(db, f)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []ExpenseReport
+	for _, o := range *orders {
+		month := o.PaymentTime.Local().Format("2006-01")
+		if len(reports) == 0 || reports[len(reports)-1].Month != month {
+			reports = append(reports, ExpenseReport{Month: month, Currency: o.Currency, VatRate: vatRate})
+		}
+
+		lines, err := expenseLines(db, o)
+		if err != nil {
+			return nil, err
+		}
+
+		r := &reports[len(reports)-1]
+		r.Orders = append(r.Orders, ExpenseOrder{BusinessOrder: o, Lines: lines})
+		r.Total += o.PaymentAmount
+	}
+
+	return reports, nil
+}
+
+// expenseLines lists the items, fees and tip of the order, with whatever
+// else was charged or discounted as an adjustment so the lines add up to
+// the amount paid.
+func expenseLines(db *sqlx.DB, o storage.BusinessOrder) ([]ExpenseLine, error) {
+	items, err := storage.GetOrderItems(db, o.AccountId, o.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []ExpenseLine
+	remaining := o.PaymentAmount
+
+	for _, i := range *items {
+		lines = append(lines, ExpenseLine{Description: i.ItemName, Count: i.Count, Amount: i.EndAmount})
+		remaining -= i.EndAmount
+	}
+
+	for _, fee := range []ExpenseLine{
+		{Description: "Delivery", Count: 1, Amount: o.DeliveryPrice},
+		{Description: "Service fee", Count: 1, Amount: o.ServiceFee},
+		{Description: "Tip", Count: 1, Amount: o.Tip},
+	} {
+		if fee.Amount != 0 {
+			lines = append(lines, fee)
+			remaining -= fee.Amount
+		}
+	}
+
+	if remaining != 0 {
+		lines = append(lines, ExpenseLine{Description: "Adjustments", Count: 1, Amount: remaining})
+	}
+
+	return lines, nil
+}
+
+func WriteExpenseCSV(w io.Writer, r ExpenseReport) error {
+	rows := [][]string{{
+		"date",
+		"order_number",
+		"venue_name",
+		"delivery_alias",
+		"description",
+		"count",
+		"amount",
+		"currency",
+	}}
+
+	for _, o := range r.Orders {
+		for _, l := range o.Lines {
+			rows = append(rows, []string{
+				o.PaymentTime.Local().Format("2006-01-02"),
+				o.OrderNumber,
+				o.VenueName,
+				o.DeliveryAlias,
+				l.Description,
+				strconv.Itoa(l.Count),
+				Money(l.Amount),
+				r.Currency,
+			})
+		}
+	}
+
+	rows = append(rows, []string{"", "", "", "", "Total incl. VAT", "", Money(r.Total), r.Currency})
+	if r.VatRate != 0 {
+		rows = append(rows, []string{"", "", "", "", "VAT " + strconv.FormatFloat(r.VatRate, 'f', -1, 64) + "%", "", Money(r.Vat()), r.Currency})
+	}
+
+	cw := csv.NewWriter(w)
+
+	return cw.WriteAll(rows)
+}
+
+var expenseTemplate = template.Must(template.New("expense").Funcs(template.FuncMap{
+	"money": Money,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <title>Expense report {{ .Month }}</title>
+    <style>
+        body { font-family: sans-serif; margin: 2em; }
+        table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; page-break-inside: avoid; }
+        th, td { text-align: left; padding: 0.25em 0.5em; border-bottom: 1px solid #ddd; }
+        td.amount, th.amount { text-align: right; }
+        tfoot td { font-weight: bold; }
+    </style>
+</head>
+<body>
+<h1>Expense report {{ .Month }}</h1>
+{{- range .Orders }}
+<table>
+    <caption style="text-align:left"><strong>{{ .VenueName }}</strong>, {{ .PaymentTime.Local.Format "2006-01-02 15:04" }}, order {{ .OrderNumber }}{{ if .DeliveryAlias }}, {{ .DeliveryAlias }}{{ end }}</caption>
+    <thead><tr><th>Description</th><th class="amount">Count</th><th class="amount">Amount ({{ $.Currency }})</th></tr></thead>
+    <tbody>
+    {{- range .Lines }}
+        <tr><td>{{ .Description }}</td><td class="amount">{{ .Count }}</td><td class="amount">{{ money .Amount }}</td></tr>
+    {{- end }}
+    </tbody>
+    <tfoot><tr><td>Order total</td><td></td><td class="amount">{{ money .PaymentAmount }}</td></tr></tfoot>
+</table>
+{{- end }}
+<table>
+    <tr><td><strong>Total incl. VAT</strong></td><td class="amount"><strong>{{ money .Total }} {{ .Currency }}</strong></td></tr>
+    {{- if .VatRate }}
+    <tr><td>VAT {{ .VatRate }}%</td><td class="amount">{{ money .Vat }} {{ .Currency }}</td></tr>
+    {{- end }}
+</table>
+</body>
+</html>
+`))
+
+func WriteExpenseHTML(w io.Writer, r ExpenseReport) error {
+	return expenseTemplate.Execute(w, r)
+}
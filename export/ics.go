@@ -0,0 +1,93 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"frederikhs/wolt/wolt"
+	"io"
+	"strings"
+	"time"
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+// WriteICS writes an iCalendar with an event per order, spanning from the
+// payment to the delivery of the order.
+func WriteICS(w io.Writer, orders *[]wolt.FullOrder) error {
+	bw := bufio.NewWriter(w)
+
+	writeICSLine(bw, "BEGIN:VCALENDAR")
+	writeICSLine(bw, "VERSION:2.0")
+	writeICSLine(bw, "PRODID:-//frederikhs//wolt//EN")
+	writeICSLine(bw, "CALSCALE:GREGORIAN")
+
+	for _, o := range *orders {
+		if o.PaymentTime.Date == 0 {
+			continue
+		}
+
+		start := time.UnixMilli(o.PaymentTime.Date).UTC()
+
+		end := o.DeliveryTime.Date
+		if end == 0 {
+			end = o.DeliveryEta.Date
+		}
+
+		status := "CONFIRMED"
+		if o.Status != "delivered" {
+			status = "CANCELLED"
+		}
+
+		description := strings.Join([]string{
+			"Venue: " + o.VenueName,
+			"Address: " + o.VenueFullAddress,
+			fmt.Sprintf("Total: %s %s", Money(o.PaymentAmount), o.Currency),
+			"Order number: " + o.OrderNumber,
+			"Status: " + o.Status,
+		}, "\n")
+
+		writeICSLine(bw, "BEGIN:VEVENT")
+		writeICSLine(bw, "UID:"+o.OrderId+"@wolt.com")
+		writeICSLine(bw, "DTSTAMP:"+start.Format(icsTimeFormat))
+		writeICSLine(bw, "DTSTART:"+start.Format(icsTimeFormat))
+		if end != 0 && end > o.PaymentTime.Date {
+			writeICSLine(bw, "DTEND:"+time.UnixMilli(end).UTC().Format(icsTimeFormat))
+		}
+		writeICSLine(bw, "SUMMARY:"+icsText("Wolt: "+o.VenueName))
+		writeICSLine(bw, "LOCATION:"+icsText(o.VenueFullAddress))
+		writeICSLine(bw, "DESCRIPTION:"+icsText(description))
+		writeICSLine(bw, "STATUS:"+status)
+		if o.VenueUrl != "" {
+			writeICSLine(bw, "URL:"+o.VenueUrl)
+		}
+		writeICSLine(bw, "END:VEVENT")
+	}
+
+	writeICSLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line ended by CRLF, folded so no line is
+// longer than 75 octets without splitting a utf-8 sequence.
+func writeICSLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+
+		// continuation lines start with the folding space
+		limit = 74
+	}
+
+	w.WriteString(line + "\r\n")
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/wolt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Posting struct {
+	Account string
+	Amount  int
+}
+
+type Transaction struct {
+	Date        time.Time
+	Payee       string
+	OrderId     string
+	OrderNumber string
+	Currency    string
+	Postings    []Posting
+}
+
+// Transactions turns every delivered order into a balanced transaction
+// paid from the account its payment method maps to. Whatever the fee
+// breakdown does not explain, such as credits and discounts, is posted to
+// the adjustments account.
+func Transactions(orders *[]wolt.FullOrder, l config.Ledger) []Transaction {
+	var txs []Transaction
+
+	for _, o := range *orders {
+		if o.Status != "delivered" {
+			continue
+		}
+
+		delivery := o.DeliveryPrice + o.DeliveryDistanceSurcharge
+		serviceFee := o.ServiceFee + o.DeliverySizeSurcharge
+		adjustments := o.PaymentAmount - o.ItemsPrice - delivery - serviceFee - o.Tip
+
+		postings := []Posting{
+			{Account: l.Accounts.Food, Amount: o.ItemsPrice},
+			{Account: l.Accounts.Delivery, Amount: delivery},
+			{Account: l.Accounts.ServiceFee, Amount: serviceFee},
+			{Account: l.Accounts.Tip, Amount: o.Tip},
+			{Account: l.Accounts.Adjustments, Amount: adjustments},
+		}
+
+		tx := Transaction{
+			Date:        time.UnixMilli(o.PaymentTime.Date),
+			Payee:       o.VenueName,
+			OrderId:     o.OrderId,
+			OrderNumber: o.OrderNumber,
+			Currency:    o.Currency,
+		}
+
+		for _, p := range postings {
+			if p.Amount != 0 {
+				tx.Postings = append(tx.Postings, p)
+			}
+		}
+
+		tx.Postings = append(tx.Postings, Posting{
+			Account: l.PaymentAccount(o.PaymentMethod.Provider, o.PaymentMethod.Type, o.PaymentMethod.Id, o.PaymentName),
+			Amount:  -o.PaymentAmount,
+		})
+
+		txs = append(txs, tx)
+	}
+
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].Date.Before(txs[j].Date)
+	})
+
+	return txs
+}
+
+// WriteLedger writes the transactions in the journal format read by both
+// ledger and hledger.
+func WriteLedger(w io.Writer, txs []Transaction) error {
+	bw := bufio.NewWriter(w)
+
+	for _, tx := range txs {
+		fmt.Fprintf(bw, "%s (%s) %s\n", tx.Date.Format("2006-01-02"), tx.OrderNumber, tx.Payee)
+		fmt.Fprintf(bw, "    ; order_id: %s\n", tx.OrderId)
+		for _, p := range tx.Postings {
+			fmt.Fprintf(bw, "    %-40s  %s %s\n", p.Account, Money(p.Amount), tx.Currency)
+		}
+		fmt.Fprintln(bw)
+	}
+
+	return bw.Flush()
+}
+
+// WriteBeancount writes the transactions as beancount, preceded by open
+// directives for every account they use.
+func WriteBeancount(w io.Writer, txs []Transaction) error {
+	bw := bufio.NewWriter(w)
+
+	if len(txs) > 0 {
+		opened := map[string]bool{}
+		var accounts []string
+		for _, tx := range txs {
+			for _, p := range tx.Postings {
+				if !opened[p.Account] {
+					opened[p.Account] = true
+					accounts = append(accounts, p.Account)
+				}
+			}
+		}
+		sort.Strings(accounts)
+
+		for _, a := range accounts {
+			fmt.Fprintf(bw, "%s open %s\n", txs[0].Date.Format("2006-01-02"), a)
+		}
+		fmt.Fprintln(bw)
+	}
+
+	for _, tx := range txs {
+		fmt.Fprintf(bw, "%s * %s %s\n", tx.Date.Format("2006-01-02"), beancountString(tx.Payee), beancountString("Wolt order "+tx.OrderNumber))
+		fmt.Fprintf(bw, "  order_id: %s\n", beancountString(tx.OrderId))
+		for _, p := range tx.Postings {
+			fmt.Fprintf(bw, "  %-40s  %s %s\n", p.Account, Money(p.Amount), tx.Currency)
+		}
+		fmt.Fprintln(bw)
+	}
+
+	return bw.Flush()
+}
+
+func beancountString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -6,6 +6,5 @@ require (
 	github.com/go-echarts/go-echarts/v2 v2.2.4
 	github.com/jmoiron/sqlx v1.3.5
 	github.com/mattn/go-sqlite3 v1.14.14
+	gopkg.in/yaml.v3 v3.0.1
 )
-
-require gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"frederikhs/wolt/export"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email sends the summary as a plain text mail through the smtp server at
+// Host and Port, authenticating when a username is set.
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (e *Email) Notify(s Summary) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+
+	return smtp.SendMail(addr, auth, e.From, e.To, e.message(s))
+}
+
+func (e *Email) message(s Summary) []byte {
+	var body bytes.Buffer
+
+	fmt.Fprintf(&body, "%d new orders\r\n", len(s.NewOrders))
+	for _, o := range s.NewOrders {
+		paid := ""
+		if o.PaymentTime != nil {
+			paid = o.PaymentTime.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(&body, "  %s  %s  %s %s  %s\r\n", paid, o.VenueName, export.Money(o.PaymentAmount), o.Currency, o.Status)
+	}
+
+	for _, b := range s.Budgets {
+		state := "within budget"
+		if b.Exceeded {
+			state = "EXCEEDED"
+		}
+		fmt.Fprintf(&body, "\r\nBudget %s: %s of %s, projected %s, %s", b.Period, export.Money(b.Spend), export.Money(b.Budget), export.Money(b.Projected), state)
+	}
+	body.WriteString("\r\n")
+
+	subject := fmt.Sprintf("Wolt: %d new orders", len(s.NewOrders))
+	for _, b := range s.Budgets {
+		if b.Exceeded {
+			subject += ", budget exceeded"
+			break
+		}
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes()
+}
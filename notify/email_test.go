@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeSmtp accepts a single mail on a local port and records what the client
+// sent.
+type fakeSmtp struct {
+	listener net.Listener
+	auth     string
+	from     string
+	to       []string
+	data     string
+	done     chan error
+}
+
+func newFakeSmtp(t *testing.T) *fakeSmtp {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeSmtp{listener: l, done: make(chan error, 1)}
+	go func() {
+		f.done <- f.serve()
+	}()
+
+	return f
+}
+
+func (f *fakeSmtp) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSmtp) serve() error {
+	conn, err := f.listener.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	defer f.listener.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		for _, l := range lines {
+			fmt.Fprintf(conn, "%s\r\n", l)
+		}
+	}
+
+	reply("220 localhost fake smtp")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-localhost", "250 AUTH PLAIN")
+		case "AUTH":
+			f.auth = strings.TrimPrefix(line, "AUTH PLAIN ")
+			reply("235 authenticated")
+		case "MAIL":
+			f.from = line
+			reply("250 ok")
+		case "RCPT":
+			f.to = append(f.to, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return err
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			f.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return nil
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+func TestEmailSendsSummary(t *testing.T) {
+	server := newFakeSmtp(t)
+
+	e := &Email{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "me@example.com",
+		Password: "secret",
+		From:     "wolt@example.com",
+		To:       []string{"me@example.com", "you@example.com"},
+	}
+
+	s := Summary{
+		NewOrders: []OrderSummary{{OrderId: "o1", VenueName: "Pizza Place", PaymentAmount: 12900, Currency: "DKK", Status: "delivered"}},
+		Budgets:   []BudgetSummary{{Period: "2021-01", Spend: 60000, Budget: 50000, Exceeded: true}},
+	}
+
+	err := e.Notify(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = <-server.done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := base64.StdEncoding.DecodeString(server.auth)
+	if err != nil || string(auth) != "\x00me@example.com\x00secret" {
+		t.Errorf("auth = %q", auth)
+	}
+	if !strings.HasPrefix(server.from, "MAIL FROM:<wolt@example.com>") {
+		t.Errorf("from = %q", server.from)
+	}
+	if len(server.to) != 2 {
+		t.Errorf("recipients = %q, want 2", server.to)
+	}
+
+	for _, want := range []string{
+		"Subject: Wolt: 1 new orders, budget exceeded\r\n",
+		"Pizza Place  129.00 DKK  delivered",
+		"Budget 2021-01: 600.00 of 500.00",
+		"EXCEEDED",
+	} {
+		if !strings.Contains(server.data, want) {
+			t.Errorf("mail does not contain %q:\n%s", want, server.data)
+		}
+	}
+}
+
+func TestEmailFailsWithoutServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	e := &Email{Host: "127.0.0.1", Port: port, From: "wolt@example.com", To: []string{"me@example.com"}}
+	err = e.Notify(Summary{})
+	if err == nil {
+		t.Fatal("expected an error without a server on port " + strconv.Itoa(port))
+	}
+}
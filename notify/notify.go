@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"frederikhs/wolt/budget"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/wolt"
+	"time"
+)
+
+// Summary is what a sync reports: the orders it found that were not stored
+// before and the state of the current budgets. Amounts are in minor units.
+type Summary struct {
+	NewOrders []OrderSummary  `json:"new_orders"`
+	Budgets   []BudgetSummary `json:"budgets"`
+}
+
+type OrderSummary struct {
+	OrderId       string     `json:"order_id"`
+	OrderNumber   string     `json:"order_number"`
+	VenueName     string     `json:"venue_name"`
+	Status        string     `json:"status"`
+	PaymentTime   *time.Time `json:"payment_time"`
+	PaymentAmount int        `json:"payment_amount"`
+	Currency      string     `json:"currency"`
+}
+
+type BudgetSummary struct {
+	Period    string `json:"period"`
+	Spend     int    `json:"spend"`
+	Budget    int    `json:"budget"`
+	Projected int    `json:"projected"`
+	Exceeded  bool   `json:"exceeded"`
+}
+
+type Notifier interface {
+	Notify(s Summary) error
+}
+
+func FromConfig(c config.Notify) []Notifier {
+	var notifiers []Notifier
+
+	if c.Webhook != nil {
+		notifiers = append(notifiers, NewWebhook(c.Webhook.Url, c.Webhook.Headers))
+	}
+
+	if c.Smtp != nil {
+		port := c.Smtp.Port
+		if port == 0 {
+			port = 587
+		}
+
+		notifiers = append(notifiers, &Email{
+			Host:     c.Smtp.Host,
+			Port:     port,
+			Username: c.Smtp.Username,
+			Password: c.Smtp.Password,
+			From:     c.Smtp.From,
+			To:       c.Smtp.To,
+		})
+	}
+
+	return notifiers
+}
+
+func NewSummary(newOrders []wolt.FullOrder, periods []budget.Period) Summary {
+	s := Summary{
+		NewOrders: []OrderSummary{},
+		Budgets:   []BudgetSummary{},
+	}
+
+	for _, o := range newOrders {
+		s.NewOrders = append(s.NewOrders, OrderSummary{
+			OrderId:       o.OrderId,
+			OrderNumber:   o.OrderNumber,
+			VenueName:     o.VenueName,
+			Status:        o.Status,
+			PaymentTime:   wolt.UnixOrNil(o.PaymentTime.Date),
+			PaymentAmount: o.PaymentAmount,
+			Currency:      o.Currency,
+		})
+	}
+
+	for _, p := range periods {
+		s.Budgets = append(s.Budgets, BudgetSummary{
+			Period:    p.Name,
+			Spend:     p.Spend,
+			Budget:    p.Budget,
+			Projected: p.Projected,
+			Exceeded:  p.Exceeded(),
+		})
+	}
+
+	return s
+}
+
+// Worth tells if the summary has anything to notify about.
+func (s Summary) Worth() bool {
+	if len(s.NewOrders) > 0 {
+		return true
+	}
+
+	for _, b := range s.Budgets {
+		if b.Exceeded {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook posts the summary as json to URL.
+type Webhook struct {
+	URL        string
+	Headers    map[string]string
+	HttpClient *http.Client
+}
+
+func NewWebhook(url string, headers map[string]string) *Webhook {
+	return &Webhook{
+		URL:     url,
+		Headers: headers,
+		HttpClient: &http.Client{
+			Timeout: time.Second * 10,
+		},
+	}
+}
+
+func (w *Webhook) Notify(s Summary) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := w.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook got http status code: %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookPostsSummary(t *testing.T) {
+	var got Summary
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("content type = %q, want application/json", ct)
+		}
+		header = r.Header.Get("X-Token")
+
+		err := json.NewDecoder(r.Body).Decode(&got)
+		if err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	s := Summary{
+		NewOrders: []OrderSummary{{OrderId: "o1", VenueName: "Pizza Place", PaymentAmount: 12900, Currency: "DKK"}},
+		Budgets:   []BudgetSummary{{Period: "2021-01", Spend: 60000, Budget: 50000, Exceeded: true}},
+	}
+
+	err := NewWebhook(server.URL, map[string]string{"X-Token": "secret"}).Notify(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header != "secret" {
+		t.Errorf("X-Token = %q, want secret", header)
+	}
+	if len(got.NewOrders) != 1 || got.NewOrders[0].OrderId != "o1" || got.NewOrders[0].PaymentAmount != 12900 {
+		t.Errorf("new orders = %+v", got.NewOrders)
+	}
+	if len(got.Budgets) != 1 || !got.Budgets[0].Exceeded {
+		t.Errorf("budgets = %+v", got.Budgets)
+	}
+}
+
+func TestWebhookFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL, nil).Notify(Summary{})
+	if err == nil {
+		t.Fatal("expected an error for status 500")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/storage"
+	"os"
+	"strconv"
+)
+
+func Rate(args []string) error {
+	fs := flag.NewFlagSet("rate", flag.ExitOnError)
+	item := fs.String("item", "", "rate a single dish of the order, by item name or id")
+	review := fs.String("review", "", "review text")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fmt.Printf("usage: %s rate [-item NAME] [-review TEXT] <ORDER_ID> <1-5>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	rating, err := strconv.Atoi(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("invalid rating: %w", err)
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	r := storage.Rating{OrderId: fs.Arg(0), Rating: rating, Review: *review}
+
+	if *item != "" {
+		r.ItemId, err = storage.ResolveItemId(db, r.OrderId, *item)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = storage.CheckOrderId(db, r.OrderId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return storage.SaveRating(db, r)
+}
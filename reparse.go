@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/storage"
+	"frederikhs/wolt/wolt"
+	"github.com/jmoiron/sqlx"
+	"os"
+)
+
+// Reparse rebuilds the synced tables from the raw order archive without
+// fetching, picking up fields FullOrder learned since the orders were fetched.
+func Reparse(args []string) error {
+	fs := flag.NewFlagSet("reparse", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fmt.Printf("usage: %s reparse\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	accounts, orders, problems, err := archivedOrders(db)
+	if err != nil {
+		return err
+	}
+
+	if len(accounts) == 0 {
+		return fmt.Errorf("no orders archived, run sync first")
+	}
+
+	return rebuild(db, accounts, orders, problems)
+}
+
+// archivedOrders decodes the raw order archive by account, along with a
+// problem for every order that does not decode.
+func archivedOrders(db *sqlx.DB) ([]string, map[string][]wolt.FullOrder, map[string][]wolt.ValidationError, error) {
+	raw, err := storage.GetRawOrders(db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var accounts []string
+	orders := map[string][]wolt.FullOrder{}
+	problems := map[string][]wolt.ValidationError{}
+	for _, r := range *raw {
+		if _, ok := orders[r.AccountId]; !ok {
+			accounts = append(accounts, r.AccountId)
+			orders[r.AccountId] = nil
+		}
+
+		o, problem := wolt.DecodeOrder(r.Data)
+		if problem != nil {
+			problems[r.AccountId] = append(problems[r.AccountId], *problem)
+			continue
+		}
+
+		orders[r.AccountId] = append(orders[r.AccountId], o)
+	}
+
+	return accounts, orders, problems, nil
+}
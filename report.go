@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/report"
+	"frederikhs/wolt/storage"
+	"os"
+	"strings"
+)
+
+func Report(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	assets := fs.String("assets", string(report.AssetsEmbed), "include chart assets as embed (self-contained) or cdn")
+	definition := fs.String("definition", "", "report definition file (yaml or json) selecting the sections")
+	out := fs.String("out", "wolt.html", "file to write the report to")
+	tags := fs.String("tags", "", "comma separated tags every order in the report must have")
+	account := fs.String("account", "", "only include the orders of this account")
+	configPath := fs.String("config", config.DefaultPath, "config file with the budgets")
+	top := fs.Int("top", 25, "number of venues shown in venue rankings before the rest is summed as other, 0 shows all")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fmt.Printf("usage: %s report [-definition report.yaml] [-assets embed|cdn] [-top 25] [-config wolt.yaml] [-tags a,b] [-account NAME] [-out wolt.html]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	assetMode, err := report.ParseAssetMode(*assets)
+	if err != nil {
+		return err
+	}
+
+	c, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	def, err := loadDefinition(*definition, c, *top)
+	if err != nil {
+		return err
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var tagList []string
+	if *tags != "" {
+		tagList = strings.Split(*tags, ",")
+	}
+
+	page, err := report.BuildPage(db, def, storage.Filter{Tags: tagList, Account: *account})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return report.Render(page, file, assetMode)
+}
+
+// loadDefinition reads the report definition at path, or the default one
+// extended with budget sections for the budgets in c when path is empty.
+func loadDefinition(path string, c *config.Config, top int) (*report.Definition, error) {
+	def := report.DefaultDefinition
+	if path != "" {
+		d, err := report.LoadDefinition(path)
+		if err != nil {
+			return nil, err
+		}
+		def = *d
+	} else {
+		if c.Budget.Monthly > 0 {
+			def.Sections = append(append([]report.Section{}, def.Sections...), report.Section{Type: "budget_monthly"})
+		}
+		if c.Budget.Weekly > 0 {
+			def.Sections = append(append([]report.Section{}, def.Sections...), report.Section{Type: "budget_weekly"})
+		}
+	}
+
+	return def.WithDefaultLimit(top).WithBudgets(c.Budget), nil
+}
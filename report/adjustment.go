@@ -0,0 +1,22 @@
+package report
+
+import (
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/jmoiron/sqlx"
+)
+
+func adjustmentsPerMonth(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.AdjustmentPeriod, error) {
+	return storage.GetAdjustmentsByMonth(db, f)
+}
+
+// AdjustmentsPerMonthChart stacks the discounts, refunds and other
+// adjustments of every month by their type.
+func AdjustmentsPerMonthChart(s Section, data *[]storage.AdjustmentPeriod) components.Charter {
+	var values []periodValue
+	for _, r := range *data {
+		values = append(values, periodValue{Period: r.Period, Series: r.Type, Value: r.Amount})
+	}
+
+	return stackedPeriodChart(s.Title, values)
+}
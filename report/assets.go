@@ -0,0 +1,87 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"io"
+	"strings"
+)
+
+//go:generate curl -sSfL -o assets/echarts.min.js https://go-echarts.github.io/go-echarts-assets/assets/echarts.min.js
+
+//go:embed assets
+var assets embed.FS
+
+type AssetMode string
+
+const (
+	AssetsEmbed AssetMode = "embed"
+	AssetsCDN   AssetMode = "cdn"
+)
+
+// embeddedChartAsset is the asset every chart needs, which is committed here
+// and updated with go generate.
+const embeddedChartAsset = "echarts.min.js"
+
+func ParseAssetMode(s string) (AssetMode, error) {
+	switch AssetMode(s) {
+	case AssetsEmbed:
+		if _, err := readAsset(embeddedChartAsset); err != nil {
+			return "", err
+		}
+		return AssetsEmbed, nil
+	case AssetsCDN:
+		return AssetsCDN, nil
+	}
+
+	return "", fmt.Errorf("unknown asset mode %q, expected %q or %q", s, AssetsEmbed, AssetsCDN)
+}
+
+// Render writes the page as html. In embed mode every javascript and css
+// asset the charts reference is inlined, so the output works offline.
+func Render(page *components.Page, w io.Writer, mode AssetMode) error {
+	if mode == AssetsCDN {
+		return page.Render(w)
+	}
+
+	var buf bytes.Buffer
+	err := page.Render(&buf)
+	if err != nil {
+		return err
+	}
+
+	html := buf.String()
+
+	for _, src := range page.JSAssets.Values {
+		b, err := readAsset(strings.TrimPrefix(src, page.AssetsHost))
+		if err != nil {
+			return err
+		}
+
+		html = strings.Replace(html, fmt.Sprintf(`<script src="%s"></script>`, src), "<script>"+string(b)+"</script>", 1)
+	}
+
+	for _, href := range page.CSSAssets.Values {
+		b, err := readAsset(strings.TrimPrefix(href, page.AssetsHost))
+		if err != nil {
+			return err
+		}
+
+		html = strings.Replace(html, fmt.Sprintf(`<link href="%s" rel="stylesheet">`, href), "<style>"+string(b)+"</style>", 1)
+	}
+
+	_, err = io.WriteString(w, html)
+
+	return err
+}
+
+func readAsset(name string) ([]byte, error) {
+	b, err := assets.ReadFile("assets/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("asset %s is not embedded, run go generate ./report or render with cdn assets", name)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+)
+
+const blurhashCharacters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// decodeBlurhash renders the blurhash of an image at width by height, see
+// https://github.com/woltapp/blurhash for the format.
+func decodeBlurhash(hash string, width, height int) (image.Image, error) {
+	if len(hash) < 6 {
+		return nil, fmt.Errorf("blurhash %q is too short", hash)
+	}
+
+	sizeFlag, err := decode83(hash[0:1])
+	if err != nil {
+		return nil, err
+	}
+	numX := sizeFlag%9 + 1
+	numY := sizeFlag/9 + 1
+
+	if len(hash) != 4+2*numX*numY {
+		return nil, fmt.Errorf("blurhash %q should be %d characters long", hash, 4+2*numX*numY)
+	}
+
+	quantisedMax, err := decode83(hash[1:2])
+	if err != nil {
+		return nil, err
+	}
+	maxValue := float64(quantisedMax+1) / 166
+
+	colors := make([][3]float64, numX*numY)
+	for i := range colors {
+		if i == 0 {
+			v, err := decode83(hash[2:6])
+			if err != nil {
+				return nil, err
+			}
+
+			colors[i] = [3]float64{sRGBToLinear(v >> 16), sRGBToLinear(v >> 8 & 255), sRGBToLinear(v & 255)}
+			continue
+		}
+
+		v, err := decode83(hash[4+i*2 : 6+i*2])
+		if err != nil {
+			return nil, err
+		}
+
+		colors[i] = [3]float64{
+			signPow(float64(v/(19*19)-9)/9, 2) * maxValue,
+			signPow(float64(v/19%19-9)/9, 2) * maxValue,
+			signPow(float64(v%19-9)/9, 2) * maxValue,
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var c [3]float64
+			for j := 0; j < numY; j++ {
+				for i := 0; i < numX; i++ {
+					basis := math.Cos(math.Pi*float64(x*i)/float64(width)) * math.Cos(math.Pi*float64(y*j)/float64(height))
+					for k := range c {
+						c[k] += colors[i+j*numX][k] * basis
+					}
+				}
+			}
+
+			img.SetNRGBA(x, y, color.NRGBA{R: linearToSRGB(c[0]), G: linearToSRGB(c[1]), B: linearToSRGB(c[2]), A: 255})
+		}
+	}
+
+	return img, nil
+}
+
+// blurhashDataURI renders the blurhash as a small png data uri, to be
+// stretched over the space of the image it stands in for.
+func blurhashDataURI(hash string) (string, error) {
+	img, err := decodeBlurhash(hash, 32, 20)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func decode83(s string) (int, error) {
+	v := 0
+	for _, r := range s {
+		i := strings.IndexRune(blurhashCharacters, r)
+		if i < 0 {
+			return 0, fmt.Errorf("invalid blurhash character %q", r)
+		}
+		v = v*83 + i
+	}
+
+	return v, nil
+}
+
+func sRGBToLinear(v int) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) uint8 {
+	c := math.Max(0, math.Min(1, v))
+	if c <= 0.0031308 {
+		return uint8(math.Round(c * 12.92 * 255))
+	}
+
+	return uint8(math.Round((1.055*math.Pow(c, 1/2.4) - 0.055) * 255))
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBlurhash(t *testing.T) {
+	tests := []struct {
+		name   string
+		hash   string
+		width  int
+		height int
+		pixels map[image.Point]color.NRGBA
+		err    string
+	}{
+		{
+			name:   "uniform",
+			hash:   "00TNoS",
+			width:  2,
+			height: 2,
+			pixels: map[image.Point]color.NRGBA{
+				{0, 0}: {R: 255, G: 128, B: 0, A: 255},
+				{1, 1}: {R: 255, G: 128, B: 0, A: 255},
+			},
+		},
+		{
+			name:   "demo",
+			hash:   "LEHV6nWB2yk8pyo0adR*.7kCMdnj",
+			width:  32,
+			height: 32,
+			pixels: map[image.Point]color.NRGBA{
+				{0, 0}:   {R: 135, G: 164, B: 177, A: 255},
+				{31, 0}:  {R: 137, G: 166, B: 181, A: 255},
+				{16, 16}: {R: 158, G: 125, B: 108, A: 255},
+				{0, 31}:  {R: 136, G: 144, B: 147, A: 255},
+				{31, 31}: {R: 133, G: 142, B: 147, A: 255},
+			},
+		},
+		{name: "too short", hash: "00TNo", width: 2, height: 2, err: "too short"},
+		{name: "wrong length", hash: "LEHV6nWB2yk8pyo0adR*.7kCMdn", width: 2, height: 2, err: "should be 28 characters long"},
+		{name: "invalid character", hash: "00TN\"S", width: 2, height: 2, err: "invalid blurhash character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodeBlurhash(tt.hash, tt.width, tt.height)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if size := img.Bounds().Size(); size != image.Pt(tt.width, tt.height) {
+				t.Errorf("size = %v, want %dx%d", size, tt.width, tt.height)
+			}
+
+			for p, want := range tt.pixels {
+				if got := color.NRGBAModel.Convert(img.At(p.X, p.Y)); got != want {
+					t.Errorf("pixel %v = %v, want %v", p, got, want)
+				}
+			}
+		})
+	}
+}
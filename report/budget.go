@@ -0,0 +1,96 @@
+package report
+
+import (
+	"frederikhs/wolt/budget"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// BudgetHistory is the spend per period against the budget, with the
+// current period projected to its end. Amounts are in minor units.
+type BudgetHistory struct {
+	Periods   []storage.PeriodSpend `json:"periods"`
+	Budget    int                   `json:"budget"`
+	Current   string                `json:"current"`
+	Projected int                   `json:"projected"`
+}
+
+func monthlyBudget(db *sqlx.DB, s Section, f storage.Filter) (*BudgetHistory, error) {
+	rows, err := storage.GetSpendByMonth(db, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return budgetHistory(db, *rows, config.Budget{Monthly: s.Budget}, f)
+}
+
+func weeklyBudget(db *sqlx.DB, s Section, f storage.Filter) (*BudgetHistory, error) {
+	rows, err := storage.GetSpendByWeek(db, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return budgetHistory(db, *rows, config.Budget{Weekly: s.Budget}, f)
+}
+
+func budgetHistory(db *sqlx.DB, rows []storage.PeriodSpend, c config.Budget, f storage.Filter) (*BudgetHistory, error) {
+	h := &BudgetHistory{Periods: rows}
+
+	f.From, f.To = nil, nil
+	current, err := budget.Current(db, c, f, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(current) == 1 {
+		h.Budget = current[0].Budget
+		h.Current = current[0].Name
+		h.Projected = current[0].Projected
+	}
+
+	return h, nil
+}
+
+func BudgetChart(s Section, h *BudgetHistory) components.Charter {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: s.Title}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width: "1500px",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}),
+		charts.WithLegendOpts(opts.Legend{Show: true, Right: "10%"}),
+	)
+
+	var periods []string
+	spends := make([]opts.BarData, 0)
+	projected := make([]opts.BarData, 0)
+	for _, p := range h.Periods {
+		periods = append(periods, p.Period)
+		spends = append(spends, opts.BarData{Value: p.Spend / 100})
+
+		if p.Period == h.Current {
+			projected = append(projected, opts.BarData{Value: h.Projected / 100})
+		} else {
+			projected = append(projected, opts.BarData{Value: "-"})
+		}
+	}
+
+	bar.SetXAxis(periods).
+		AddSeries("Spend", spends).
+		AddSeries("Projected", projected)
+
+	if h.Budget > 0 {
+		bar.SetSeriesOptions(charts.WithMarkLineNameYAxisItemOpts(opts.MarkLineNameYAxisItem{
+			Name:  "Budget",
+			YAxis: h.Budget / 100,
+		}))
+	}
+
+	return bar
+}
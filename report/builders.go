@@ -0,0 +1,221 @@
+package report
+
+import (
+	"fmt"
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/jmoiron/sqlx"
+)
+
+// Builder produces one section of a report, either as a chart or as the raw
+// data behind it.
+type Builder struct {
+	Title string
+	Build func(db *sqlx.DB, s Section, f storage.Filter) (components.Charter, error)
+	Data  func(db *sqlx.DB, s Section, f storage.Filter) (interface{}, error)
+}
+
+func builder[T any](title string, data func(db *sqlx.DB, s Section, f storage.Filter) (T, error), chart func(s Section, data T) components.Charter) Builder {
+	return Builder{
+		Title: title,
+		Build: func(db *sqlx.DB, s Section, f storage.Filter) (components.Charter, error) {
+			d, err := data(db, s, f)
+			if err != nil {
+				return nil, err
+			}
+
+			return chart(s, d), nil
+		},
+		Data: func(db *sqlx.DB, s Section, f storage.Filter) (interface{}, error) {
+			return data(db, s, f)
+		},
+	}
+}
+
+// Builders holds every section type a report definition can refer to.
+var Builders = map[string]Builder{
+	"orders_per_week":           builder("Orders per week", ordersPerWeek, OrdersPerWeekChart),
+	"venues_by_spend":           builder("Venues by total spend", venueRanking(storage.GetTopVenuesByTotalSpend), CreateTopVenueChart),
+	"venues_by_orders":          builder("Venues by total number of orders", venueRanking(storage.GetTopVenuesByTotalNumberOfOrders), CreateTopVenueChart),
+	"venues_by_delivery_spend":  builder("Venues by total spend on delivery", venueRanking(storage.GetTopVenuesByTotalSpendOnDelivery), CreateTopVenueChart),
+	"total_spends":              builder("Total spends", totalSpends, TotalSpendsChart),
+	"ratings_by_venue":          builder("Average rating by venue", venueRatings, RatingsByVenueChart),
+	"rating_vs_price":           builder("Rating by order price", ratedOrders, RatingVsPriceChart),
+	"rating_vs_delivery_time":   builder("Rating by delivery time", ratedOrders, RatingVsDeliveryTimeChart),
+	"best_value":                builder("Best value venues, rating per 100 spent", venuesByValue, BestValueChart),
+	"budget_monthly":            builder("Spend per month against budget", monthlyBudget, BudgetChart),
+	"budget_weekly":             builder("Spend per week against budget", weeklyBudget, BudgetChart),
+	"group_orders":              builder("Group orders, our share against the full total", groupOrders, GroupOrdersChart),
+	"payment_methods_by_spend":  builder("Spend by payment method", paymentMethodsBySpend, PaymentMethodsBySpendChart),
+	"payment_methods_per_month": builder("Spend per payment method per month", paymentMethodsPerMonth, PaymentMethodsPerMonthChart),
+	"addon_spend":               builder("Spend on items and add-ons per month", addonSpend, AddonSpendChart),
+	"common_customizations":     builder("Most common customizations", commonCustomizations, CommonCustomizationsChart),
+	"usual_orders":              builder("Usual order per venue, number of orders", usualOrders, UsualOrdersChart),
+	"dish_prices":               builder("Price change per dish, percent", dishPrices, DishPricesChart),
+	"venue_inflation":           builder("Yearly price increase per venue, percent", venueInflation, VenueInflationChart),
+	"inflation_index":           builder("Personal food price index", inflationIndex, InflationIndexChart),
+	"item_changes":              builder("Items substituted or removed by the venue", itemChanges, ItemChangesChart),
+	"item_change_impact":        builder("Price impact of substituted and removed items", itemChanges, ItemChangeImpactChart),
+	"adjustments_per_month":     builder("Discounts and refunds per month", adjustmentsPerMonth, AdjustmentsPerMonthChart),
+	"venues_by_adjustments":     builder("Venues by total discounts and refunds", venueRanking(storage.GetTopVenuesByAdjustments), CreateTopVenueChart),
+	"venue_cards":               builder("Venues", venueCards, VenueCardsChart),
+}
+
+// BuildPage assembles the sections of the definition, each computed over the
+// orders matching base and the date range of the section.
+func BuildPage(db *sqlx.DB, d *Definition, base storage.Filter) (*components.Page, error) {
+	page := components.NewPage()
+	page.PageTitle = d.Title
+	page.Renderer = pageRender{page: page}
+
+	accounts, err := storage.GetAccounts(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range d.Sections {
+		b, ok := Builders[s.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown section type %q", s.Type)
+		}
+
+		if s.Title == "" {
+			s.Title = b.Title
+		}
+
+		for _, s := range s.PerAccountSections(*accounts) {
+			f, err := s.Filter(base)
+			if err != nil {
+				return nil, err
+			}
+
+			c, err := b.Build(db, s, f)
+			if err != nil {
+				return nil, fmt.Errorf("section %s: %w", s.Type, err)
+			}
+
+			page.AddCharts(c)
+		}
+	}
+
+	return page, nil
+}
+
+func venueRanking(query func(db *sqlx.DB, f storage.Filter, limit int) (*[]storage.VenueAgg, error)) func(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.VenueAgg, error) {
+	return func(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.VenueAgg, error) {
+		return query(db, f, s.Limit)
+	}
+}
+
+func ordersPerWeek(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.OrderDay, error) {
+	return storage.GetNumberOfOrdersByDateRange(db, f)
+}
+
+func totalSpends(db *sqlx.DB, s Section, f storage.Filter) (*storage.TotalFoodAndDeliverySpend, error) {
+	return storage.GetTotalFoodAndDeliverySpend(db, f)
+}
+
+func OrdersPerWeekChart(s Section, orderDays *[]storage.OrderDay) components.Charter {
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: s.Title}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width: "1500px",
+		}),
+	)
+
+	var dates []string
+	for _, i := range *orderDays {
+		dates = append(dates, i.Date)
+	}
+
+	orderCount := make([]opts.LineData, 0)
+	for _, i := range *orderDays {
+		orderCount = append(orderCount, opts.LineData{Value: i.Count})
+	}
+
+	line.SetXAxis(dates).
+		AddSeries("Orders", orderCount)
+
+	return line
+}
+
+func TotalSpendsChart(s Section, totalSpends *storage.TotalFoodAndDeliverySpend) components.Charter {
+	pie := charts.NewPie()
+	pie.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: s.Title}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width: "1500px",
+		}),
+	)
+
+	pie.AddSeries("pie", []opts.PieData{
+		{
+			Name:  "Food sum",
+			Value: totalSpends.TotalFood,
+		}, {
+			Name:  "Delivery sum",
+			Value: totalSpends.TotalDelivery,
+		},
+	}).
+		SetSeriesOptions(charts.WithLabelOpts(
+			opts.Label{
+				Show:      true,
+				Formatter: "{b}: {c}",
+			}),
+		)
+
+	return pie
+}
+
+func CreateTopVenueChart(s Section, data *[]storage.VenueAgg) components.Charter {
+	var names []string
+	for _, i := range *data {
+		names = append(names, i.VenueName)
+	}
+
+	spends := make([]opts.BarData, 0)
+	for _, i := range *data {
+		spends = append(spends, opts.BarData{Value: i.VenueValue})
+	}
+
+	return rankingChart(s.Title, names, spends)
+}
+
+// rankingChart is a horizontal bar chart with the first bar at the bottom.
+func rankingChart(title string, names []string, values []opts.BarData) *charts.Bar {
+	// create a new bar instance
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
+		Title: title,
+	}), charts.WithInitializationOpts(opts.Initialization{
+		Width:  "1500px",
+		Height: venueChartHeight(len(names)),
+	}))
+
+	// Put data into instance
+	bar.SetXAxis(names).
+		AddSeries("value", values).
+		SetSeriesOptions(
+			charts.WithLabelOpts(opts.Label{
+				Show:     true,
+				Position: "right",
+			}),
+		)
+	bar.XYReversal()
+
+	return bar
+}
+
+// venueChartHeight gives every bar of a venue chart the same room no matter
+// how many venues it shows.
+func venueChartHeight(bars int) string {
+	height := 100 + bars*30
+	if height < 300 {
+		height = 300
+	}
+
+	return fmt.Sprintf("%dpx", height)
+}
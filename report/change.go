@@ -0,0 +1,62 @@
+package report
+
+import (
+	"fmt"
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/jmoiron/sqlx"
+)
+
+func itemChanges(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.VenueItemChanges, error) {
+	return storage.GetItemChangesByVenue(db, f, s.Limit)
+}
+
+// ItemChangesChart stacks the substituted, removed and changed items of every
+// venue, labelled with the share of its items they make up.
+func ItemChangesChart(s Section, data *[]storage.VenueItemChanges) components.Charter {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: s.Title}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:  "1500px",
+			Height: venueChartHeight(len(*data)),
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}),
+		charts.WithLegendOpts(opts.Legend{Show: true, Right: "10%"}),
+	)
+
+	var names []string
+	substituted := make([]opts.BarData, 0)
+	removed := make([]opts.BarData, 0)
+	quantityChanged := make([]opts.BarData, 0)
+	for i := len(*data) - 1; i >= 0; i-- {
+		v := (*data)[i]
+		changed := v.Substituted + v.Removed + v.QuantityChanged
+		names = append(names, fmt.Sprintf("%s (%s, %.0f%% of %d items)", v.VenueName, v.ProductLine, float64(changed)*100/float64(v.Items), v.Items))
+		substituted = append(substituted, opts.BarData{Value: v.Substituted})
+		removed = append(removed, opts.BarData{Value: v.Removed})
+		quantityChanged = append(quantityChanged, opts.BarData{Value: v.QuantityChanged})
+	}
+
+	stack := charts.WithBarChartOpts(opts.BarChart{Stack: "changes"})
+	bar.SetXAxis(names).
+		AddSeries("Substituted", substituted, stack).
+		AddSeries("Removed", removed, stack).
+		AddSeries("Quantity changed", quantityChanged, stack)
+	bar.XYReversal()
+
+	return bar
+}
+
+func ItemChangeImpactChart(s Section, data *[]storage.VenueItemChanges) components.Charter {
+	var names []string
+	values := make([]opts.BarData, 0)
+	for i := len(*data) - 1; i >= 0; i-- {
+		names = append(names, (*data)[i].VenueName)
+		values = append(values, opts.BarData{Value: float64((*data)[i].Impact) / 100})
+	}
+
+	return rankingChart(s.Title, names, values)
+}
@@ -0,0 +1,184 @@
+package report
+
+import (
+	"fmt"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/storage"
+	"gopkg.in/yaml.v3"
+	"os"
+	"time"
+)
+
+// Definition selects the sections of a report and their order. It is read
+// from yaml, which also accepts json.
+type Definition struct {
+	Title    string    `yaml:"title"`
+	Sections []Section `yaml:"sections"`
+}
+
+type Section struct {
+	Type  string `yaml:"type"`
+	Title string `yaml:"title"`
+	From  string `yaml:"from"`
+	To    string `yaml:"to"`
+	Limit int    `yaml:"limit"`
+	// Tags limits the section to orders with every tag, on top of the tags
+	// the whole report is filtered by.
+	Tags []string `yaml:"tags"`
+	// Budget is the budget per period of budget sections in major units,
+	// taken from the config when not set.
+	Budget float64 `yaml:"budget"`
+	// ProductLine limits the section to venues of a product line, like
+	// grocery.
+	ProductLine string `yaml:"product_line"`
+	// Account limits the section to one account, and PerAccount repeats it
+	// for every account. Sections combine all accounts otherwise.
+	Account    string `yaml:"account"`
+	PerAccount bool   `yaml:"per_account"`
+}
+
+var DefaultDefinition = Definition{
+	Title: "Wolt report",
+	Sections: []Section{
+		{Type: "orders_per_week"},
+		{Type: "venues_by_spend"},
+		{Type: "venues_by_orders"},
+		{Type: "venues_by_delivery_spend"},
+		{Type: "total_spends"},
+		{Type: "venue_cards"},
+	},
+}
+
+func LoadDefinition(path string) (*Definition, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var d Definition
+	err = yaml.Unmarshal(b, &d)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range d.Sections {
+		if _, ok := Builders[s.Type]; !ok {
+			return nil, fmt.Errorf("%s: unknown section type %q", path, s.Type)
+		}
+
+		if _, err := s.Filter(storage.Filter{}); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return &d, nil
+}
+
+// WithDefaultLimit returns a copy of the definition where every section
+// without a limit of its own is limited to n.
+func (d Definition) WithDefaultLimit(n int) *Definition {
+	sections := make([]Section, len(d.Sections))
+	for i, s := range d.Sections {
+		if s.Limit == 0 {
+			s.Limit = n
+		}
+		sections[i] = s
+	}
+	d.Sections = sections
+
+	return &d
+}
+
+// WithBudgets returns a copy of the definition where budget sections without
+// a budget of their own get the budget of their period from c.
+func (d Definition) WithBudgets(c config.Budget) *Definition {
+	sections := make([]Section, len(d.Sections))
+	for i, s := range d.Sections {
+		if s.Budget == 0 {
+			switch s.Type {
+			case "budget_monthly":
+				s.Budget = c.Monthly
+			case "budget_weekly":
+				s.Budget = c.Weekly
+			}
+		}
+		sections[i] = s
+	}
+	d.Sections = sections
+
+	return &d
+}
+
+// Filter narrows base to the date range of the section.
+func (s Section) Filter(base storage.Filter) (storage.Filter, error) {
+	f, err := DateFilter(s.From, s.To)
+	if err != nil {
+		return f, fmt.Errorf("section %s: %w", s.Type, err)
+	}
+
+	if base.From != nil && (f.From == nil || base.From.After(*f.From)) {
+		f.From = base.From
+	}
+
+	if base.To != nil && (f.To == nil || base.To.Before(*f.To)) {
+		f.To = base.To
+	}
+
+	f.Venue = base.Venue
+	f.ProductLine = base.ProductLine
+	if s.ProductLine != "" {
+		f.ProductLine = s.ProductLine
+	}
+	f.Status = base.Status
+	f.Account = base.Account
+	if s.Account != "" {
+		f.Account = s.Account
+	}
+	f.Tags = append(append([]string{}, base.Tags...), s.Tags...)
+
+	return f, nil
+}
+
+// PerAccountSections returns the section once per account when it is shown
+// per account, with the account added to its title.
+func (s Section) PerAccountSections(accounts []string) []Section {
+	if !s.PerAccount {
+		return []Section{s}
+	}
+
+	var sections []Section
+	for _, a := range accounts {
+		c := s
+		c.Account = a
+		c.PerAccount = false
+		c.Title = fmt.Sprintf("%s (%s)", s.Title, a)
+		sections = append(sections, c)
+	}
+
+	return sections
+}
+
+// DateFilter returns a filter for the date range between from and to, both
+// given as inclusive dates and optional.
+func DateFilter(from, to string) (storage.Filter, error) {
+	var f storage.Filter
+
+	if from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("invalid from date: %w", err)
+		}
+		f.From = &t
+	}
+
+	if to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("invalid to date: %w", err)
+		}
+		t = t.AddDate(0, 0, 1)
+		f.To = &t
+	}
+
+	return f, nil
+}
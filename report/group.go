@@ -0,0 +1,43 @@
+package report
+
+import (
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/jmoiron/sqlx"
+)
+
+func groupOrders(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.GroupOrderPeriod, error) {
+	return storage.GetGroupOrdersByMonth(db, f)
+}
+
+func GroupOrdersChart(s Section, rows *[]storage.GroupOrderPeriod) components.Charter {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: s.Title}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width: "1500px",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}),
+		charts.WithLegendOpts(opts.Legend{Show: true, Right: "10%"}),
+	)
+
+	var periods []string
+	shares := make([]opts.BarData, 0)
+	totals := make([]opts.BarData, 0)
+	paidAsHost := make([]opts.BarData, 0)
+	for _, r := range *rows {
+		periods = append(periods, r.Period)
+		shares = append(shares, opts.BarData{Value: r.Share / 100})
+		totals = append(totals, opts.BarData{Value: r.Total / 100})
+		paidAsHost = append(paidAsHost, opts.BarData{Value: r.PaidAsHost / 100})
+	}
+
+	bar.SetXAxis(periods).
+		AddSeries("Our share", shares).
+		AddSeries("Full total", totals).
+		AddSeries("Paid for others as host", paidAsHost)
+
+	return bar
+}
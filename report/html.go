@@ -0,0 +1,56 @@
+package report
+
+import (
+	"bytes"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/render"
+	"github.com/go-echarts/go-echarts/v2/templates"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// htmlSection is a section of plain html rather than a chart, placed on the
+// page among the charts.
+type htmlSection struct {
+	HTML template.HTML
+}
+
+func (htmlSection) Type() string { return "html" }
+
+func (htmlSection) GetAssets() opts.Assets { return opts.Assets{} }
+
+func (htmlSection) Validate() {}
+
+// pageTemplate is the go-echarts page template, where every chart renders as
+// before and html sections render their html.
+var pageTemplate = render.MustTemplate("page", []string{
+	templates.HeaderTpl,
+	strings.Replace(templates.BaseTpl, `define "base"`, `define "chart"`, 1),
+	`{{- define "base" }}{{ if eq .Type "html" }}{{ .HTML }}{{ else }}{{ template "chart" . }}{{ end }}{{ end }}`,
+	templates.PageTpl,
+})
+
+// functionMarker marks the javascript functions in chart options, the
+// go-echarts renderer removes it the same way.
+var functionMarker = regexp.MustCompile(`(__f__")|("__f__)|(__f__)`)
+
+type pageRender struct {
+	page *components.Page
+}
+
+func (r pageRender) Render(w io.Writer) error {
+	r.page.Validate()
+
+	var buf bytes.Buffer
+	err := pageTemplate.ExecuteTemplate(&buf, "page", r.page)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(functionMarker.ReplaceAll(buf.Bytes(), nil))
+
+	return err
+}
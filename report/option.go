@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/jmoiron/sqlx"
+)
+
+func addonSpend(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.AddonSpend, error) {
+	return storage.GetAddonSpendByMonth(db, f)
+}
+
+func commonCustomizations(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.Customization, error) {
+	return storage.GetCommonCustomizations(db, f, s.Limit)
+}
+
+func usualOrders(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.ItemCombination, error) {
+	return storage.GetUsualOrders(db, f, s.Limit)
+}
+
+func AddonSpendChart(s Section, data *[]storage.AddonSpend) components.Charter {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: s.Title}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width: "1500px",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}),
+		charts.WithLegendOpts(opts.Legend{Show: true, Right: "10%"}),
+	)
+
+	var periods []string
+	base := make([]opts.BarData, 0)
+	addons := make([]opts.BarData, 0)
+	for _, r := range *data {
+		periods = append(periods, r.Period)
+		base = append(base, opts.BarData{Value: r.Base / 100})
+		addons = append(addons, opts.BarData{Value: r.Addons / 100})
+	}
+
+	bar.SetXAxis(periods).
+		AddSeries("Items", base, charts.WithBarChartOpts(opts.BarChart{Stack: "spend"})).
+		AddSeries("Add-ons", addons, charts.WithBarChartOpts(opts.BarChart{Stack: "spend"}))
+
+	return bar
+}
+
+func CommonCustomizationsChart(s Section, data *[]storage.Customization) components.Charter {
+	var names []string
+	values := make([]opts.BarData, 0)
+	for i := len(*data) - 1; i >= 0; i-- {
+		c := (*data)[i]
+		names = append(names, fmt.Sprintf("%s: %s", c.ItemName, c.ValueName))
+		values = append(values, opts.BarData{Value: c.Times})
+	}
+
+	return rankingChart(s.Title, names, values)
+}
+
+func UsualOrdersChart(s Section, data *[]storage.ItemCombination) components.Charter {
+	var names []string
+	values := make([]opts.BarData, 0)
+	for i := len(*data) - 1; i >= 0; i-- {
+		c := (*data)[i]
+		name := fmt.Sprintf("%s: %s", c.VenueName, c.ItemName)
+		if c.Options != "" {
+			name = fmt.Sprintf("%s (%s)", name, c.Options)
+		}
+		names = append(names, name)
+		values = append(values, opts.BarData{Value: c.Orders})
+	}
+
+	return rankingChart(s.Title, names, values)
+}
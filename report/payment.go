@@ -0,0 +1,91 @@
+package report
+
+import (
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/jmoiron/sqlx"
+	"sort"
+)
+
+func paymentMethodsBySpend(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.PaymentMethodSpend, error) {
+	return storage.GetPaymentMethodsBySpend(db, f)
+}
+
+func paymentMethodsPerMonth(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.PaymentMethodSpend, error) {
+	return storage.GetPaymentMethodSpendByMonth(db, f)
+}
+
+func PaymentMethodsBySpendChart(s Section, data *[]storage.PaymentMethodSpend) components.Charter {
+	var names []string
+	values := make([]opts.BarData, 0)
+	for i := len(*data) - 1; i >= 0; i-- {
+		names = append(names, (*data)[i].PaymentMethod)
+		values = append(values, opts.BarData{Value: (*data)[i].Spend / 100})
+	}
+
+	return rankingChart(s.Title, names, values)
+}
+
+// PaymentMethodsPerMonthChart stacks the spend of every payment method per
+// month.
+func PaymentMethodsPerMonthChart(s Section, data *[]storage.PaymentMethodSpend) components.Charter {
+	var values []periodValue
+	for _, r := range *data {
+		values = append(values, periodValue{Period: r.Period, Series: r.PaymentMethod, Value: r.Spend})
+	}
+
+	return stackedPeriodChart(s.Title, values)
+}
+
+// periodValue is an amount in minor units of a series in a period.
+type periodValue struct {
+	Period string
+	Series string
+	Value  int
+}
+
+// stackedPeriodChart stacks the series of every period, given in order of
+// their period, in major units.
+func stackedPeriodChart(title string, data []periodValue) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: title}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width: "1500px",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}),
+		charts.WithLegendOpts(opts.Legend{Show: true, Right: "10%"}),
+	)
+
+	var periods []string
+	values := map[string]map[string]int{}
+	for _, r := range data {
+		if len(periods) == 0 || periods[len(periods)-1] != r.Period {
+			periods = append(periods, r.Period)
+		}
+
+		if values[r.Series] == nil {
+			values[r.Series] = map[string]int{}
+		}
+		values[r.Series][r.Period] = r.Value
+	}
+
+	var series []string
+	for name := range values {
+		series = append(series, name)
+	}
+	sort.Strings(series)
+
+	bar.SetXAxis(periods)
+	for _, name := range series {
+		bars := make([]opts.BarData, 0)
+		for _, p := range periods {
+			bars = append(bars, opts.BarData{Value: values[name][p] / 100})
+		}
+		bar.AddSeries(name, bars, charts.WithBarChartOpts(opts.BarChart{Stack: "total"}))
+	}
+
+	return bar
+}
@@ -0,0 +1,252 @@
+package report
+
+import (
+	"fmt"
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/jmoiron/sqlx"
+	"math"
+	"sort"
+	"time"
+)
+
+// minInflationDays is the shortest time between the first and the latest
+// price of a dish for it to count towards the inflation of its venue, as
+// shorter ones blow up when annualized.
+const minInflationDays = 30
+
+// DishPriceChange is how the price of a dish changed from when it was first
+// ordered to its latest price, in minor units and percent.
+type DishPriceChange struct {
+	VenueId    string  `json:"venue_id"`
+	VenueName  string  `json:"venue_name"`
+	ItemName   string  `json:"item_name"`
+	FirstPrice int     `json:"first_price"`
+	LastPrice  int     `json:"last_price"`
+	FirstSeen  string  `json:"first_seen"`
+	LastChange string  `json:"last_change"`
+	LastSeen   string  `json:"last_seen"`
+	Changes    int     `json:"changes"`
+	Percent    float64 `json:"percent"`
+}
+
+// VenueInflation is the average yearly price increase of the dishes of a
+// venue, in percent.
+type VenueInflation struct {
+	VenueId   string  `json:"venue_id"`
+	VenueName string  `json:"venue_name"`
+	Dishes    int     `json:"dishes"`
+	Percent   float64 `json:"percent"`
+}
+
+// InflationMonth is the personal food price index of a month, starting at
+// 100, and its change from the previous month in percent.
+type InflationMonth struct {
+	Month   string  `json:"month"`
+	Index   float64 `json:"index"`
+	Percent float64 `json:"percent"`
+}
+
+// dishPriceChanges reduces the price history to the first and latest price
+// of every dish.
+func dishPriceChanges(history []storage.ItemPrice) []DishPriceChange {
+	var changes []DishPriceChange
+	for i, p := range history {
+		if i > 0 && history[i-1].VenueId == p.VenueId && history[i-1].ItemId == p.ItemId {
+			c := &changes[len(changes)-1]
+			c.LastPrice = p.Price
+			c.LastChange = p.FirstSeen
+			c.LastSeen = p.LastSeen
+			c.Changes++
+			continue
+		}
+
+		changes = append(changes, DishPriceChange{
+			VenueId:    p.VenueId,
+			VenueName:  p.VenueName,
+			ItemName:   p.ItemName,
+			FirstPrice: p.Price,
+			LastPrice:  p.Price,
+			FirstSeen:  p.FirstSeen,
+			LastChange: p.FirstSeen,
+			LastSeen:   p.LastSeen,
+		})
+	}
+
+	for i, c := range changes {
+		if c.FirstPrice != 0 {
+			changes[i].Percent = round2(float64(c.LastPrice-c.FirstPrice) * 100 / float64(c.FirstPrice))
+		}
+	}
+
+	return changes
+}
+
+func dishPrices(db *sqlx.DB, s Section, f storage.Filter) (*[]DishPriceChange, error) {
+	history, err := storage.GetPriceHistory(db, f)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []DishPriceChange
+	for _, c := range dishPriceChanges(*history) {
+		if c.Changes > 0 {
+			changed = append(changed, c)
+		}
+	}
+
+	sort.SliceStable(changed, func(i, j int) bool {
+		return math.Abs(changed[i].Percent) > math.Abs(changed[j].Percent)
+	})
+
+	if s.Limit > 0 && len(changed) > s.Limit {
+		changed = changed[:s.Limit]
+	}
+
+	return &changed, nil
+}
+
+// venueInflation averages the yearly price increase of every dish of a venue
+// ordered over at least minInflationDays, including the ones that kept their
+// price.
+func venueInflation(db *sqlx.DB, s Section, f storage.Filter) (*[]VenueInflation, error) {
+	history, err := storage.GetPriceHistory(db, f)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []VenueInflation
+	totals := map[string]float64{}
+	for _, c := range dishPriceChanges(*history) {
+		first, _ := time.Parse("2006-01-02", c.FirstSeen)
+		last, _ := time.Parse("2006-01-02", c.LastSeen)
+		days := last.Sub(first).Hours() / 24
+
+		if days < minInflationDays || c.FirstPrice == 0 {
+			continue
+		}
+
+		if len(rows) == 0 || rows[len(rows)-1].VenueId != c.VenueId {
+			rows = append(rows, VenueInflation{VenueId: c.VenueId, VenueName: c.VenueName})
+		}
+
+		rows[len(rows)-1].Dishes++
+		totals[c.VenueId] += (math.Pow(float64(c.LastPrice)/float64(c.FirstPrice), 365/days) - 1) * 100
+	}
+
+	for i, v := range rows {
+		rows[i].Percent = round2(totals[v.VenueId] / float64(v.Dishes))
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Percent > rows[j].Percent
+	})
+
+	if s.Limit > 0 && len(rows) > s.Limit {
+		rows = rows[:s.Limit]
+	}
+
+	return &rows, nil
+}
+
+// inflationIndex chains the monthly change of what the dishes bought in a
+// month cost against their latest price before that month, weighing every
+// dish by the quantity bought. Dishes not bought before are left out of the
+// change of their first month.
+func inflationIndex(db *sqlx.DB, s Section, f storage.Filter) (*[]InflationMonth, error) {
+	purchases, err := storage.GetItemPurchases(db, f)
+	if err != nil {
+		return nil, err
+	}
+
+	var months []InflationMonth
+	previous := map[string]int{}
+	latest := map[string]int{}
+	current, then := 0, 0
+	index := 100.0
+
+	closeMonth := func() {
+		m := &months[len(months)-1]
+		if then > 0 {
+			m.Percent = round2(float64(current-then) * 100 / float64(then))
+			index = index * float64(current) / float64(then)
+		}
+		m.Index = round2(index)
+
+		for k, v := range latest {
+			previous[k] = v
+		}
+		current, then = 0, 0
+	}
+
+	for _, p := range *purchases {
+		if len(months) == 0 || months[len(months)-1].Month != p.Month {
+			if len(months) > 0 {
+				closeMonth()
+			}
+			months = append(months, InflationMonth{Month: p.Month})
+		}
+
+		key := p.VenueId + "/" + p.ItemId
+		if before, ok := previous[key]; ok {
+			current += p.Quantity * p.Price
+			then += p.Quantity * before
+		}
+		latest[key] = p.Price
+	}
+
+	if len(months) > 0 {
+		closeMonth()
+	}
+
+	return &months, nil
+}
+
+func DishPricesChart(s Section, data *[]DishPriceChange) components.Charter {
+	var names []string
+	values := make([]opts.BarData, 0)
+	for i := len(*data) - 1; i >= 0; i-- {
+		c := (*data)[i]
+		names = append(names, fmt.Sprintf("%s: %s", c.VenueName, c.ItemName))
+		values = append(values, opts.BarData{Value: c.Percent})
+	}
+
+	return rankingChart(s.Title, names, values)
+}
+
+func VenueInflationChart(s Section, data *[]VenueInflation) components.Charter {
+	var names []string
+	values := make([]opts.BarData, 0)
+	for i := len(*data) - 1; i >= 0; i-- {
+		names = append(names, (*data)[i].VenueName)
+		values = append(values, opts.BarData{Value: (*data)[i].Percent})
+	}
+
+	return rankingChart(s.Title, names, values)
+}
+
+func InflationIndexChart(s Section, data *[]InflationMonth) components.Charter {
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: s.Title}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width: "1500px",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}),
+		charts.WithYAxisOpts(opts.YAxis{Type: "value", Scale: true}),
+	)
+
+	var months []string
+	index := make([]opts.LineData, 0)
+	for _, m := range *data {
+		months = append(months, m.Month)
+		index = append(index, opts.LineData{Value: m.Index})
+	}
+
+	line.SetXAxis(months).
+		AddSeries("Index", index)
+
+	return line
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/jmoiron/sqlx"
+	"math"
+)
+
+func venueRatings(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.VenueRating, error) {
+	return storage.GetVenueRatings(db, f)
+}
+
+func venuesByValue(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.VenueRating, error) {
+	return storage.GetVenuesByValue(db, f, s.Limit)
+}
+
+func ratedOrders(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.RatedOrder, error) {
+	return storage.GetRatedOrders(db, f)
+}
+
+func RatingsByVenueChart(s Section, data *[]storage.VenueRating) components.Charter {
+	var names []string
+	values := make([]opts.BarData, 0)
+	for _, i := range *data {
+		names = append(names, i.VenueName)
+		values = append(values, opts.BarData{Value: round2(i.Rating)})
+	}
+
+	return rankingChart(s.Title, names, values)
+}
+
+func BestValueChart(s Section, data *[]storage.VenueRating) components.Charter {
+	var names []string
+	values := make([]opts.BarData, 0)
+	for _, i := range *data {
+		names = append(names, i.VenueName)
+		values = append(values, opts.BarData{Value: round2(i.Value)})
+	}
+
+	return rankingChart(s.Title, names, values)
+}
+
+func RatingVsPriceChart(s Section, data *[]storage.RatedOrder) components.Charter {
+	points := make([]opts.ScatterData, 0)
+	for _, o := range *data {
+		points = append(points, opts.ScatterData{Name: o.VenueName, Value: []interface{}{o.Price, o.Rating}})
+	}
+
+	return ratingScatter(s.Title, "Price", points)
+}
+
+func RatingVsDeliveryTimeChart(s Section, data *[]storage.RatedOrder) components.Charter {
+	points := make([]opts.ScatterData, 0)
+	for _, o := range *data {
+		points = append(points, opts.ScatterData{Name: o.VenueName, Value: []interface{}{math.Round(o.DeliveryMinutes), o.Rating}})
+	}
+
+	return ratingScatter(s.Title, "Minutes to delivery", points)
+}
+
+func ratingScatter(title, xName string, points []opts.ScatterData) *charts.Scatter {
+	scatter := charts.NewScatter()
+	scatter.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: title}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width: "1500px",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true, Formatter: "{b}: {c}"}),
+		charts.WithXAxisOpts(opts.XAxis{Type: "value", Name: xName}),
+		charts.WithYAxisOpts(opts.YAxis{Type: "value", Name: "Rating", Min: 0, Max: 5}),
+	)
+
+	scatter.AddSeries("Orders", points)
+
+	return scatter
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
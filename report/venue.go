@@ -0,0 +1,67 @@
+package report
+
+import (
+	"bytes"
+	"frederikhs/wolt/storage"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/jmoiron/sqlx"
+	"html/template"
+)
+
+func venueCards(db *sqlx.DB, s Section, f storage.Filter) (*[]storage.VenueCard, error) {
+	return storage.GetVenueCards(db, f, s.Limit)
+}
+
+var venueCardsTemplate = template.Must(template.New("venue_cards").Parse(`
+<div class="container">
+	<div class="item" style="width:1500px;font-family:sans-serif;">
+		<h3 style="color:#464646;">{{ .Title }}</h3>
+		<div style="display:flex;flex-wrap:wrap;gap:16px;">
+		{{- range .Cards }}
+			<a href="{{ .VenueUrl }}" style="width:280px;border-radius:8px;overflow:hidden;box-shadow:0 1px 4px rgba(0,0,0,0.2);color:inherit;text-decoration:none;">
+				<div style="height:140px;background-color:#eee;background-size:cover;{{ .Placeholder }}">
+				{{- if .ListImage }}
+					<img src="{{ .ListImage }}" alt="" loading="lazy" onerror="this.remove()" style="width:100%;height:100%;object-fit:cover;">
+				{{- end }}
+				</div>
+				<div style="padding:8px 12px;">
+					<div style="font-weight:bold;">{{ .VenueName }}</div>
+					<div style="font-size:0.9em;color:#666;">{{ if .VenueFullAddress }}{{ .VenueFullAddress }}{{ else }}{{ .VenueAddress }}{{ end }}</div>
+					<div style="font-size:0.9em;margin-top:4px;">{{ .Orders }} order{{ if ne .Orders 1 }}s{{ end }}</div>
+				</div>
+			</a>
+		{{- end }}
+		</div>
+	</div>
+</div>
+`))
+
+type venueCard struct {
+	storage.VenueCard
+	Placeholder template.CSS
+}
+
+// VenueCardsChart shows a card for every venue with its name, address and
+// number of orders, and the blurhash of its image rendered in place of the
+// image until it loads, or when it cannot.
+func VenueCardsChart(s Section, data *[]storage.VenueCard) components.Charter {
+	var cards []venueCard
+	for _, v := range *data {
+		c := venueCard{VenueCard: v}
+		if uri, err := blurhashDataURI(v.ListImageBlurhash); err == nil {
+			c.Placeholder = template.CSS(`background-image:url("` + uri + `");`)
+		}
+		cards = append(cards, c)
+	}
+
+	var buf bytes.Buffer
+	err := venueCardsTemplate.Execute(&buf, map[string]interface{}{
+		"Title": s.Title,
+		"Cards": cards,
+	})
+	if err != nil {
+		return htmlSection{HTML: template.HTML(template.HTMLEscapeString(err.Error()))}
+	}
+
+	return htmlSection{HTML: template.HTML(buf.String())}
+}
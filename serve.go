@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/report"
+	"frederikhs/wolt/server"
+	"frederikhs/wolt/storage"
+	"log"
+	"net/http"
+	"os"
+)
+
+func Serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	assets := fs.String("assets", string(report.AssetsEmbed), "include chart assets as embed (self-contained) or cdn")
+	definition := fs.String("definition", "", "report definition file (yaml or json) selecting the sections")
+	configPath := fs.String("config", config.DefaultPath, "config file with the budgets")
+	top := fs.Int("top", 25, "number of venues shown in venue rankings before the rest is summed as other, 0 shows all")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fmt.Printf("usage: %s serve [-addr 127.0.0.1:8080] [-definition report.yaml] [-assets embed|cdn] [-top 25] [-config wolt.yaml]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	assetMode, err := report.ParseAssetMode(*assets)
+	if err != nil {
+		return err
+	}
+
+	c, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	def, err := loadDefinition(*definition, c, *top)
+	if err != nil {
+		return err
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log.Printf("serving dashboard on http://%s\n", *addr)
+
+	return http.ListenAndServe(*addr, server.New(db, def, assetMode).Handler())
+}
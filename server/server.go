@@ -0,0 +1,258 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"frederikhs/wolt/report"
+	"frederikhs/wolt/storage"
+	"github.com/jmoiron/sqlx"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Server serves the report and the data behind every section live from the
+// database, filtered by the query parameters from, to, venue, product_line,
+// status, tag and account.
+type Server struct {
+	db         *sqlx.DB
+	definition *report.Definition
+	assets     report.AssetMode
+}
+
+func New(db *sqlx.DB, definition *report.Definition, assets report.AssetMode) *Server {
+	return &Server{
+		db:         db,
+		definition: definition,
+		assets:     assets,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleReport)
+	mux.HandleFunc("/api/", s.handleApi)
+
+	return mux
+}
+
+var filterTemplate = template.Must(template.New("filter").Parse(`
+<form method="get" style="display:flex;justify-content:center;gap:1em;margin:1em;font-family:sans-serif">
+    <label>From <input type="date" name="from" value="{{ .Query.Get "from" }}"></label>
+    <label>To <input type="date" name="to" value="{{ .Query.Get "to" }}"></label>
+    <label>Venue <select name="venue">
+        <option value="">All</option>
+        {{- range .Venues }}
+        <option{{ if eq . ($.Query.Get "venue") }} selected{{ end }}>{{ . }}</option>
+        {{- end }}
+    </select></label>
+    <label>Product line <select name="product_line">
+        <option value="">All</option>
+        {{- range .ProductLines }}
+        <option{{ if eq . ($.Query.Get "product_line") }} selected{{ end }}>{{ . }}</option>
+        {{- end }}
+    </select></label>
+    <label>Status <select name="status">
+        {{- range .Statuses }}
+        <option{{ if eq . $.Status }} selected{{ end }}>{{ . }}</option>
+        {{- end }}
+    </select></label>
+    {{- if gt (len .Accounts) 1 }}
+    <label>Account <select name="account">
+        <option value="">All</option>
+        {{- range .Accounts }}
+        <option{{ if eq . ($.Query.Get "account") }} selected{{ end }}>{{ . }}</option>
+        {{- end }}
+    </select></label>
+    {{- end }}
+    <label>Tags <input type="text" name="tag" value="{{ .Tags }}" placeholder="comma separated"></label>
+    <button type="submit">Filter</button>
+</form>
+`))
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	venues, err := storage.GetVenueNames(s.db)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	productLines, err := storage.GetProductLines(s.db)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	statuses, err := storage.GetStatuses(s.db)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	accounts, err := storage.GetAccounts(s.db)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	status := f.Status
+	if status == "" {
+		status = storage.DefaultStatus
+	}
+
+	var form bytes.Buffer
+	err = filterTemplate.Execute(&form, map[string]interface{}{
+		"Query":        r.URL.Query(),
+		"Venues":       *venues,
+		"ProductLines": *productLines,
+		"Statuses":     *statuses,
+		"Accounts":     *accounts,
+		"Status":       status,
+		"Tags":         strings.Join(f.Tags, ","),
+	})
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	page, err := report.BuildPage(s.db, s.definition, f)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	var html bytes.Buffer
+	err = report.Render(page, &html, s.assets)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(strings.Replace(html.String(), "<body>", "<body>"+form.String(), 1)))
+}
+
+// handleApi lists the section types on /api/ and returns the data of a
+// single section as json on /api/<type>.
+func (s *Server) handleApi(w http.ResponseWriter, r *http.Request) {
+	sectionType := strings.TrimPrefix(r.URL.Path, "/api/")
+
+	if sectionType == "" {
+		var types []string
+		for t := range report.Builders {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+
+		writeJson(w, types)
+		return
+	}
+
+	b, ok := report.Builders[sectionType]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// sections of the served definition carry their configured limit and
+	// budget, but not their date range, which comes from the query
+	section := report.Section{Type: sectionType, Title: b.Title}
+	for _, ds := range s.definition.Sections {
+		if ds.Type == sectionType {
+			section.Limit = ds.Limit
+			section.Budget = ds.Budget
+			section.Tags = ds.Tags
+			section.Account = ds.Account
+			section.ProductLine = ds.ProductLine
+			break
+		}
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		section.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	f, err = section.Filter(f)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	data, err := b.Data(s.db, section, f)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	writeJson(w, data)
+}
+
+func parseFilter(r *http.Request) (storage.Filter, error) {
+	q := r.URL.Query()
+
+	f, err := report.DateFilter(q.Get("from"), q.Get("to"))
+	if err != nil {
+		return f, err
+	}
+
+	f.Venue = q.Get("venue")
+	f.ProductLine = q.Get("product_line")
+	f.Status = q.Get("status")
+	f.Tags = splitTags(q["tag"]...)
+	f.Account = q.Get("account")
+
+	return f, nil
+}
+
+// splitTags splits comma separated tags, as they are entered in the filter
+// form.
+func splitTags(values ...string) []string {
+	var tags []string
+	for _, v := range values {
+		for _, tag := range strings.Split(v, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func internalError(w http.ResponseWriter, err error) {
+	log.Println(err)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
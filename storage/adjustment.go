@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// AdjustmentPeriod is the amount adjusted in our favour by a type of
+// adjustment in a period, in minor units.
+type AdjustmentPeriod struct {
+	Period string `json:"period" db:"period"`
+	Type   string `json:"type" db:"type"`
+	Orders int    `json:"orders" db:"orders"`
+	Amount int    `json:"amount" db:"amount"`
+}
+
+// GetAdjustmentsByMonth returns the discounts, refunds and other adjustments
+// per type and local calendar month.
+func GetAdjustmentsByMonth(db *sqlx.DB, f Filter) (*[]AdjustmentPeriod, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT strftime('%%Y-%%m', vwo.payment_time, 'localtime') as period,
+			   coalesce(nullif(woa.type, ''), 'unknown') as type,
+			   COUNT(DISTINCT vwo.account_id || vwo.order_id) as orders,
+			   SUM(woa.amount) as amount
+		FROM (SELECT * FROM view_wolt_order WHERE %s) vwo
+		JOIN wolt_order_adjustment woa ON woa.account_id = vwo.account_id AND woa.order_id = vwo.order_id
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`, where)
+
+	var rows []AdjustmentPeriod
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+func GetTopVenuesByAdjustments(db *sqlx.DB, f Filter, limit int) (*[]VenueAgg, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT vwo.venue_name, SUM(woa.amount) / 100 as venue_value
+		FROM (SELECT * FROM view_wolt_order WHERE %s) vwo
+		JOIN wolt_order_adjustment woa ON woa.account_id = vwo.account_id AND woa.order_id = vwo.order_id
+		GROUP BY vwo.venue_id, vwo.venue_name
+		ORDER BY SUM(woa.amount)
+	`, where)
+
+	var rows []VenueAgg
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return topVenues(rows, limit), nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+type PeriodSpend struct {
+	Period string `json:"period" db:"period"`
+	Spend  int    `json:"spend" db:"spend"`
+}
+
+// GetTotalSpend returns the amount paid for orders matching f in minor units.
+func GetTotalSpend(db *sqlx.DB, f Filter) (int, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT coalesce(SUM(payment_amount), 0)
+		FROM view_wolt_order
+		WHERE %s
+	`, where)
+
+	var spend int
+	err := db.Get(&spend, sql, args...)
+
+	return spend, err
+}
+
+// GetSpendByMonth returns the amount paid per local calendar month, as
+// 2006-01, in minor units.
+func GetSpendByMonth(db *sqlx.DB, f Filter) (*[]PeriodSpend, error) {
+	return getSpendByPeriod(db, f, "%Y-%m")
+}
+
+// GetSpendByWeek returns the amount paid per local week starting on monday,
+// as 2006-W01, in minor units.
+func GetSpendByWeek(db *sqlx.DB, f Filter) (*[]PeriodSpend, error) {
+	return getSpendByPeriod(db, f, "%Y-W%W")
+}
+
+func getSpendByPeriod(db *sqlx.DB, f Filter, format string) (*[]PeriodSpend, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT strftime(?, payment_time, 'localtime') as period, SUM(payment_amount) as spend
+		FROM view_wolt_order
+		WHERE %s
+		GROUP BY 1
+		ORDER BY 1
+	`, where)
+
+	var rows []PeriodSpend
+	err := db.Select(&rows, sql, append([]interface{}{format}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
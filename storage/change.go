@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// VenueItemChanges counts the items of the orders at a venue that were
+// substituted, removed or changed in quantity by the venue, out of the items
+// ordered. Impact is the change in price in minor units, negative when the
+// changes made the orders cheaper.
+type VenueItemChanges struct {
+	VenueName       string `json:"venue_name" db:"venue_name"`
+	ProductLine     string `json:"product_line" db:"product_line"`
+	Items           int    `json:"items" db:"items"`
+	Substituted     int    `json:"substituted" db:"substituted"`
+	Removed         int    `json:"removed" db:"removed"`
+	QuantityChanged int    `json:"quantity_changed" db:"quantity_changed"`
+	Impact          int    `json:"impact" db:"impact"`
+}
+
+// GetItemChangesByVenue returns the item changes of the venues with any,
+// most changed items first. A limit of 0 returns every venue.
+func GetItemChangesByVenue(db *sqlx.DB, f Filter, limit int) (*[]VenueItemChanges, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		WITH orders AS (
+			SELECT * FROM view_wolt_order WHERE %s
+		),
+		items AS (
+			SELECT o.venue_id, COUNT(*) as items
+			FROM orders o
+			JOIN wolt_order_item woi ON woi.account_id = o.account_id AND woi.order_id = o.order_id
+			GROUP BY 1
+		)
+		SELECT o.venue_name,
+			   o.venue_product_line as product_line,
+			   i.items,
+			   SUM(wic.kind = 'substituted') as substituted,
+			   SUM(wic.kind = 'removed') as removed,
+			   SUM(wic.kind = 'quantity_changed') as quantity_changed,
+			   SUM(coalesce(wic.new_end_amount, 0) - coalesce(wic.end_amount, 0)) as impact
+		FROM orders o
+		JOIN wolt_item_change wic ON wic.account_id = o.account_id AND wic.order_id = o.order_id
+		JOIN items i ON i.venue_id = o.venue_id
+		GROUP BY o.venue_id, o.venue_name, o.venue_product_line, i.items
+		ORDER BY substituted + removed + quantity_changed DESC, o.venue_name
+	`, where)
+
+	if limit > 0 {
+		sql += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	var rows []VenueItemChanges
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
@@ -1,94 +1,248 @@
 package storage
 
 import (
+	"fmt"
 	"frederikhs/wolt/wolt"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
+	"time"
 )
 
+// userTables hold what the user entered rather than what was synced, along
+// with the raw order archive, they are created on connect and never dropped.
+var userTables = []string{
+	createExpenseRule,
+	createOrderTag,
+	createVenueTag,
+	createNote,
+	createRating,
+	createRawOrder,
+}
+
 func Connect() (*sqlx.DB, error) {
 	db, err := sqlx.Connect("sqlite3", "wolt.db")
 	if err != nil {
 		return nil, err
 	}
 
+	for _, table := range userTables {
+		_, err = db.Exec(table)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
 	return db, nil
 }
 
-func Setup(db *sqlx.DB) {
-	db.MustExec("DROP VIEW IF EXISTS view_wolt_order")
-	db.MustExec("DROP TABLE IF EXISTS wolt_order")
-	db.MustExec("DROP TABLE IF EXISTS wolt_venue")
-	db.MustExec(`
+// Setup recreates the tables holding synced orders, leaving the user tables
+// created by Connect as they are.
+func Setup(db sqlx.Execer) {
+	sqlx.MustExec(db, "DROP VIEW IF EXISTS view_business_order")
+	sqlx.MustExec(db, "DROP VIEW IF EXISTS view_wolt_order")
+	sqlx.MustExec(db, "DROP TABLE IF EXISTS wolt_item_price")
+	sqlx.MustExec(db, "DROP TABLE IF EXISTS wolt_item_change")
+	sqlx.MustExec(db, "DROP TABLE IF EXISTS wolt_order_adjustment")
+	sqlx.MustExec(db, "DROP TABLE IF EXISTS wolt_order_item_option")
+	sqlx.MustExec(db, "DROP TABLE IF EXISTS wolt_order_item")
+	sqlx.MustExec(db, "DROP TABLE IF EXISTS wolt_order")
+	sqlx.MustExec(db, "DROP TABLE IF EXISTS wolt_venue_history")
+	sqlx.MustExec(db, "DROP TABLE IF EXISTS wolt_venue")
+	sqlx.MustExec(db, "DROP TABLE IF EXISTS wolt_account")
+	sqlx.MustExec(db, `
+		CREATE TABLE wolt_account (
+			account_id TEXT PRIMARY KEY,
+			synced_at DATETIME
+		)
+	`)
+	sqlx.MustExec(db, `
 		CREATE TABLE wolt_venue (
 		    venue_id TEXT PRIMARY KEY,
 			venue_name TEXT,
+			venue_address TEXT,
+			venue_full_address TEXT,
+			venue_country TEXT,
+			venue_phone TEXT,
+			venue_timezone TEXT,
 			venue_product_line TEXT,
 			venue_coordinate_x TEXT,
 			venue_coordinate_y TEXT,
-			venue_url TEXT
+			venue_url TEXT,
+			main_image TEXT,
+			main_image_blurhash TEXT,
+			list_image TEXT,
+			list_image_blurhash TEXT
 		)
 	`)
-	db.MustExec(`
+	sqlx.MustExec(db, `
 		CREATE TABLE wolt_order (
-		    order_id TEXT PRIMARY KEY,
+			account_id TEXT NOT NULL REFERENCES wolt_account(account_id),
+		    order_id TEXT NOT NULL,
 		    client_pre_estimate TEXT NOT NULL,
 			delivery_street TEXT,
 			delivery_coordinate_x TEXT,
 			delivery_coordinate_y TEXT,
 			delivery_distance INT,
-			delivery_eta TEXT,
+			delivery_eta DATETIME,
 			delivery_method TEXT,
 			delivery_price INT,
 			delivery_size_surcharge INT,
-			delivery_time TEXT,
+			delivery_time DATETIME,
 			driver_type TEXT,
 			items_price INT,
 			payment_amount INT,
-			payment_time TEXT,
+			payment_time DATETIME,
 			status TEXT,
 			service_fee INT,
 			subscribed BOOLEAN,
 			total_price INT,
 			venue_id TEXT REFERENCES wolt_venue(venue_id),
-			preorder_time TEXT,
-			delivery_distance_surcharge INT
+			preorder_time DATETIME,
+			delivery_distance_surcharge INT,
+			order_number TEXT,
+			delivery_alias TEXT,
+			currency TEXT,
+			tip INT,
+			is_host_paying BOOLEAN,
+			delivery_price_share INT,
+			tip_share INT,
+			total_price_share INT,
+			payment_method_id TEXT,
+			payment_provider TEXT,
+			payment_type TEXT,
+			payment_name TEXT,
+			PRIMARY KEY (account_id, order_id)
+		)
+	`)
+	sqlx.MustExec(db, `
+		CREATE TABLE wolt_order_item (
+			account_id TEXT NOT NULL,
+			order_id TEXT NOT NULL,
+			row_number INT,
+			item_id TEXT,
+			item_name TEXT,
+			count INT,
+			price INT,
+			end_amount INT,
+			substitution_allowed BOOLEAN,
+			substitutes TEXT,
+			PRIMARY KEY (account_id, order_id, row_number),
+			FOREIGN KEY (account_id, order_id) REFERENCES wolt_order(account_id, order_id)
 		)
 	`)
-	db.MustExec(`
+	sqlx.MustExec(db, `
+		CREATE TABLE wolt_order_item_option (
+			account_id TEXT NOT NULL,
+			order_id TEXT NOT NULL,
+			row_number INT,
+			option_id TEXT,
+			option_name TEXT,
+			value_id TEXT,
+			value_name TEXT,
+			count INT,
+			price INT,
+			PRIMARY KEY (account_id, order_id, row_number, option_id, value_id),
+			FOREIGN KEY (account_id, order_id, row_number) REFERENCES wolt_order_item(account_id, order_id, row_number)
+		)
+	`)
+	sqlx.MustExec(db, `
 		CREATE VIEW view_wolt_order AS
 			SELECT * FROM wolt_order
 			JOIN wolt_venue wv on wolt_order.venue_id = wv.venue_id
 	`)
+
+	sqlx.MustExec(db, `
+		CREATE TABLE wolt_item_change (
+			account_id TEXT NOT NULL,
+			order_id TEXT NOT NULL,
+			change_number INT,
+			type TEXT,
+			kind TEXT,
+			item_id TEXT,
+			item_name TEXT,
+			count INT,
+			end_amount INT,
+			new_item_id TEXT,
+			new_item_name TEXT,
+			new_count INT,
+			new_end_amount INT,
+			PRIMARY KEY (account_id, order_id, change_number),
+			FOREIGN KEY (account_id, order_id) REFERENCES wolt_order(account_id, order_id)
+		)
+	`)
+	sqlx.MustExec(db, `
+		CREATE TABLE wolt_order_adjustment (
+			account_id TEXT NOT NULL,
+			order_id TEXT NOT NULL,
+			row_number INT,
+			adjustment_id TEXT,
+			type TEXT,
+			name TEXT,
+			promo_code TEXT,
+			amount INT,
+			PRIMARY KEY (account_id, order_id, row_number),
+			FOREIGN KEY (account_id, order_id) REFERENCES wolt_order(account_id, order_id)
+		)
+	`)
+	sqlx.MustExec(db, createVenueHistory)
+	sqlx.MustExec(db, createItemPrice)
+
+	setupExpenses(db)
 }
 
-func SaveOrders(db *sqlx.DB, orders *[]wolt.FullOrder) error {
+// SaveOrders stores the orders of account, which must not have been saved
+// since Setup. It returns the validation problems of the orders, the ones
+// with a problem that skips them are left out.
+func SaveOrders(db sqlx.Ext, account string, orders *[]wolt.FullOrder) ([]wolt.ValidationError, error) {
+	_, err := db.Exec("INSERT INTO wolt_account (account_id, synced_at) VALUES (?, ?)", account, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	storable, problems := wolt.Storable(*orders)
+
 	var simpleOrders []wolt.SimpleOrder
-	var simpleVenues []wolt.SimpleVenue
-	for _, o := range *orders {
-		simpleOrders = append(simpleOrders, o.ToSimpleOrder())
-		simpleVenues = append(simpleVenues, o.ToSimpleVenue())
+	venues := map[[2]string]*VenueSnapshot{}
+	var simpleItems []wolt.SimpleItem
+	var simpleOptions []wolt.SimpleItemOption
+	var simpleChanges []wolt.SimpleItemChange
+	var simpleAdjustments []wolt.SimpleAdjustment
+	for _, o := range storable {
+		simpleOrder := o.ToSimpleOrder()
+		simpleOrder.AccountId = account
+		simpleOrders = append(simpleOrders, simpleOrder)
+		addVenueSnapshot(venues, o.ToSimpleVenue(), simpleOrder.PaymentTime)
+
+		for _, i := range o.ToSimpleItems() {
+			i.AccountId = account
+			simpleItems = append(simpleItems, i)
+		}
+
+		for _, io := range o.ToSimpleItemOptions() {
+			io.AccountId = account
+			simpleOptions = append(simpleOptions, io)
+		}
+
+		for _, c := range o.ToSimpleItemChanges() {
+			c.AccountId = account
+			simpleChanges = append(simpleChanges, c)
+		}
+
+		for _, a := range o.ToSimpleAdjustments() {
+			a.AccountId = account
+			simpleAdjustments = append(simpleAdjustments, a)
+		}
 	}
 
-	_, err := db.NamedExec(`
-		INSERT OR IGNORE INTO wolt_venue (
-			venue_id,
-			venue_name,
-			venue_product_line,
-			venue_coordinate_x,
-			venue_coordinate_y,
-			venue_url
-		) VALUES (
-		    :venue_id,
-			:venue_name,
-			:venue_product_line,
-			:venue_coordinate_x,
-			:venue_coordinate_y,
-			:venue_url
-		)
-	`, simpleVenues)
-	_, err = db.NamedExec(`
+	err = saveVenueSnapshots(db, venues)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertRows(db, `
 		INSERT INTO wolt_order (
+			account_id,
 			order_id, 
 			client_pre_estimate, 
 			delivery_street, 
@@ -110,8 +264,21 @@ func SaveOrders(db *sqlx.DB, orders *[]wolt.FullOrder) error {
 			total_price,
 			venue_id,
 			preorder_time, 
-			delivery_distance_surcharge
+			delivery_distance_surcharge,
+			order_number,
+			delivery_alias,
+			currency,
+			tip,
+			is_host_paying,
+			delivery_price_share,
+			tip_share,
+			total_price_share,
+			payment_method_id,
+			payment_provider,
+			payment_type,
+			payment_name
 		) VALUES (
+			:account_id,
 		    :order_id,
 			:client_pre_estimate,
 			:delivery_street,
@@ -133,94 +300,315 @@ func SaveOrders(db *sqlx.DB, orders *[]wolt.FullOrder) error {
 			:total_price,
 		    :venue_id,
 			:preorder_time,
-		    :delivery_distance_surcharge
+		    :delivery_distance_surcharge,
+			:order_number,
+			:delivery_alias,
+			:currency,
+			:tip,
+			:is_host_paying,
+			:delivery_price_share,
+			:tip_share,
+			:total_price_share,
+			:payment_method_id,
+			:payment_provider,
+			:payment_type,
+			:payment_name
 		)
 	`, simpleOrders)
+	if err != nil {
+		return nil, err
+	}
 
+	err = insertRows(db, `
+		INSERT INTO wolt_order_item (
+			account_id,
+			order_id,
+			row_number,
+			item_id,
+			item_name,
+			count,
+			price,
+			end_amount,
+			substitution_allowed,
+			substitutes
+		) VALUES (
+			:account_id,
+			:order_id,
+			:row_number,
+			:item_id,
+			:item_name,
+			:count,
+			:price,
+			:end_amount,
+			:substitution_allowed,
+			:substitutes
+		)
+	`, simpleItems)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertRows(db, `
+		INSERT INTO wolt_order_item_option (
+			account_id,
+			order_id,
+			row_number,
+			option_id,
+			option_name,
+			value_id,
+			value_name,
+			count,
+			price
+		) VALUES (
+			:account_id,
+			:order_id,
+			:row_number,
+			:option_id,
+			:option_name,
+			:value_id,
+			:value_name,
+			:count,
+			:price
+		)
+	`, simpleOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertRows(db, `
+		INSERT INTO wolt_item_change (
+			account_id,
+			order_id,
+			change_number,
+			type,
+			kind,
+			item_id,
+			item_name,
+			count,
+			end_amount,
+			new_item_id,
+			new_item_name,
+			new_count,
+			new_end_amount
+		) VALUES (
+			:account_id,
+			:order_id,
+			:change_number,
+			:type,
+			:kind,
+			:item_id,
+			:item_name,
+			:count,
+			:end_amount,
+			:new_item_id,
+			:new_item_name,
+			:new_count,
+			:new_end_amount
+		)
+	`, simpleChanges)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertRows(db, `
+		INSERT INTO wolt_order_adjustment (
+			account_id,
+			order_id,
+			row_number,
+			adjustment_id,
+			type,
+			name,
+			promo_code,
+			amount
+		) VALUES (
+			:account_id,
+			:order_id,
+			:row_number,
+			:adjustment_id,
+			:type,
+			:name,
+			:promo_code,
+			:amount
+		)
+	`, simpleAdjustments)
+	if err != nil {
+		return nil, err
+	}
+
+	return problems, nil
+}
+
+// maxVariables is the lowest limit on the number of variables in a statement
+// across sqlite builds.
+const maxVariables = 999
+
+// insertRows runs the named insert for the rows, in batches small enough to
+// stay below maxVariables. sqlx refuses to insert no rows, so none is a no-op.
+func insertRows[T any](db sqlx.Ext, query string, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	_, args, err := sqlx.Named(query, rows[0])
 	if err != nil {
 		return err
 	}
 
+	batch := len(rows)
+	if len(args) > 0 {
+		batch = maxVariables / len(args)
+	}
+
+	for start := 0; start < len(rows); start += batch {
+		end := start + batch
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		_, err = sqlx.NamedExec(db, query, rows[start:end])
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+func GetSavedOrders(db *sqlx.DB) (*[]wolt.SimpleOrder, error) {
+	var rows []wolt.SimpleOrder
+	err := db.Select(&rows, "SELECT * FROM wolt_order ORDER BY payment_time, order_id, account_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+func GetSavedVenues(db *sqlx.DB) (*[]wolt.SimpleVenue, error) {
+	var rows []wolt.SimpleVenue
+	err := db.Select(&rows, "SELECT * FROM wolt_venue ORDER BY venue_name, venue_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+func GetSavedItems(db *sqlx.DB) (*[]wolt.SimpleItem, error) {
+	var rows []wolt.SimpleItem
+	err := db.Select(&rows, `
+		SELECT woi.* FROM wolt_order_item woi
+		JOIN wolt_order wo ON wo.account_id = woi.account_id AND wo.order_id = woi.order_id
+		ORDER BY wo.payment_time, woi.order_id, woi.account_id, woi.row_number
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
 type VenueAgg struct {
-	VenueName  string `db:"venue_name"`
-	VenueValue int    `db:"venue_value"`
+	VenueName  string `json:"venue_name" db:"venue_name"`
+	VenueValue int    `json:"venue_value" db:"venue_value"`
 }
 
-func GetTopVenuesByTotalSpend(db *sqlx.DB) (*[]VenueAgg, error) {
-	sql := `
+const OtherVenuesName = "Other"
+
+// topVenues keeps the limit venues with the highest value from rows sorted
+// in ascending order and sums the remaining ones into a single "Other" row
+// placed first. A limit of 0 keeps every venue.
+func topVenues(rows []VenueAgg, limit int) *[]VenueAgg {
+	if limit <= 0 || len(rows) <= limit {
+		return &rows
+	}
+
+	other := VenueAgg{VenueName: OtherVenuesName}
+	for _, r := range rows[:len(rows)-limit] {
+		other.VenueValue += r.VenueValue
+	}
+
+	top := append([]VenueAgg{other}, rows[len(rows)-limit:]...)
+
+	return &top
+}
+
+func GetTopVenuesByTotalSpend(db *sqlx.DB, f Filter, limit int) (*[]VenueAgg, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
 		SELECT DISTINCT vwo.venue_name, agg.total_spend / 100 as venue_value FROM view_wolt_order vwo
 		JOIN (SELECT venue_id, SUM(payment_amount) as total_spend
 			  FROM view_wolt_order
-			  WHERE status = 'delivered'
+			  WHERE %s
 			  GROUP BY venue_id) agg ON agg.venue_id = vwo.venue_id
 		ORDER BY agg.total_spend
-	`
+	`, where)
 
 	var rows []VenueAgg
-	err := db.Select(&rows, sql)
+	err := db.Select(&rows, sql, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &rows, nil
+	return topVenues(rows, limit), nil
 }
 
-func GetTopVenuesByTotalNumberOfOrders(db *sqlx.DB) (*[]VenueAgg, error) {
-	sql := `
+func GetTopVenuesByTotalNumberOfOrders(db *sqlx.DB, f Filter, limit int) (*[]VenueAgg, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
 		SELECT DISTINCT vwo.venue_name, agg.count as venue_value FROM view_wolt_order vwo
 		JOIN (SELECT venue_id, COUNT(*) as count
 			  FROM view_wolt_order
-			  WHERE status = 'delivered'
+			  WHERE %s
 			  GROUP BY venue_id) agg ON agg.venue_id = vwo.venue_id
 		ORDER BY agg.count	
-	`
+	`, where)
 
 	var rows []VenueAgg
-	err := db.Select(&rows, sql)
+	err := db.Select(&rows, sql, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &rows, nil
+	return topVenues(rows, limit), nil
 }
 
-func GetTopVenuesByTotalSpendOnDelivery(db *sqlx.DB) (*[]VenueAgg, error) {
-	sql := `
+func GetTopVenuesByTotalSpendOnDelivery(db *sqlx.DB, f Filter, limit int) (*[]VenueAgg, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
 		SELECT DISTINCT vwo.venue_name, agg.count / 100 as venue_value FROM view_wolt_order vwo
 		JOIN (SELECT venue_id, SUM(delivery_price) as count
 			FROM view_wolt_order
-			WHERE status = 'delivered'
+			WHERE %s
 			GROUP BY venue_id) agg ON agg.venue_id = vwo.venue_id
 		ORDER BY agg.count
-	`
+	`, where)
 
 	var rows []VenueAgg
-	err := db.Select(&rows, sql)
+	err := db.Select(&rows, sql, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &rows, nil
+	return topVenues(rows, limit), nil
 }
 
 type TotalFoodAndDeliverySpend struct {
-	TotalFood     int `db:"sum_food"`
-	TotalDelivery int `db:"sum_delivery"`
+	TotalFood     int `json:"sum_food" db:"sum_food"`
+	TotalDelivery int `json:"sum_delivery" db:"sum_delivery"`
 }
 
-func GetTotalFoodAndDeliverySpend(db *sqlx.DB) (*TotalFoodAndDeliverySpend, error) {
-	sql := `
-		SELECT (SUM(payment_amount) - SUM(delivery_price)) / 100 as sum_food,
-			    SUM(delivery_price) / 100 as sum_delivery
+func GetTotalFoodAndDeliverySpend(db *sqlx.DB, f Filter) (*TotalFoodAndDeliverySpend, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT coalesce((SUM(payment_amount) - SUM(delivery_price)) / 100, 0) as sum_food,
+			    coalesce(SUM(delivery_price) / 100, 0) as sum_delivery
 		FROM view_wolt_order vwo
-		WHERE status = 'delivered'
-	`
+		WHERE %s
+	`, where)
 
 	var rows []TotalFoodAndDeliverySpend
-	err := db.Select(&rows, sql)
+	err := db.Select(&rows, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -229,28 +617,77 @@ func GetTotalFoodAndDeliverySpend(db *sqlx.DB) (*TotalFoodAndDeliverySpend, erro
 }
 
 type OrderDay struct {
-	Date  string `db:"yearweek"`
-	Count int    `db:"count"`
+	Date  string `json:"yearweek" db:"yearweek"`
+	Count int    `json:"count" db:"count"`
 }
 
-func GetNumberOfOrdersByDateRange(db *sqlx.DB) (*[]OrderDay, error) {
-	sql := `
+func GetNumberOfOrdersByDateRange(db *sqlx.DB, f Filter) (*[]OrderDay, error) {
+	start, end := "2019-10-10", time.Now().Format("2006-01-02")
+	if f.From != nil {
+		start = f.From.Format("2006-01-02")
+	}
+	if f.To != nil {
+		end = f.To.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+
+	where, args := f.where()
+	sql := fmt.Sprintf(`
 		SELECT a.yearweek, coalesce(b.count, 0) as count
 		FROM (WITH RECURSIVE cnt(x) AS (SELECT 0
 										UNION ALL
 										SELECT x + 1
 										FROM cnt
-										LIMIT (SELECT ((julianday(date()) - julianday('2019-10-10'))) + 1))
-			  SELECT DISTINCT strftime('%Y%W', julianday('2019-10-10'), '+' || x || ' days') as yearweek
+										LIMIT (SELECT ((julianday(?) - julianday(?))) + 1))
+			  SELECT DISTINCT strftime('%%Y%%W', julianday(?), '+' || x || ' days') as yearweek
 			  FROM cnt) a
-				 LEFT JOIN (SELECT strftime('%Y%W', payment_time) as yearweek, COUNT(*) as count
+				 LEFT JOIN (SELECT strftime('%%Y%%W', payment_time) as yearweek, COUNT(*) as count
 					   FROM view_wolt_order
-					   WHERE status = 'delivered'
+					   WHERE %s
 					   GROUP BY 1) b ON a.yearweek = b.yearweek
-	`
+	`, where)
 
 	var rows []OrderDay
-	err := db.Select(&rows, sql)
+	err := db.Select(&rows, sql, append([]interface{}{end, start, start}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+func GetVenueNames(db *sqlx.DB) (*[]string, error) {
+	var rows []string
+	err := db.Select(&rows, "SELECT DISTINCT venue_name FROM wolt_venue ORDER BY venue_name")
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+func GetProductLines(db *sqlx.DB) (*[]string, error) {
+	var rows []string
+	err := db.Select(&rows, "SELECT DISTINCT venue_product_line FROM wolt_venue ORDER BY venue_product_line")
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+func GetStatuses(db *sqlx.DB) (*[]string, error) {
+	var rows []string
+	err := db.Select(&rows, "SELECT DISTINCT status FROM wolt_order ORDER BY status")
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+func GetAccounts(db *sqlx.DB) (*[]string, error) {
+	var rows []string
+	err := db.Select(&rows, "SELECT account_id FROM wolt_account ORDER BY account_id")
 	if err != nil {
 		return nil, err
 	}
@@ -7,40 +7,82 @@ import (
 	"frederikhs/wolt/wolt"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
+// DefaultAccount is the account of orders synced with a token given on the
+// command line, stored in JsonFilename.
+const DefaultAccount = "default"
+
 const JsonFilename = "orders.json"
 
-func WriteOrders(order *[]wolt.FullOrder) error {
+// JsonFilenameFor returns the file the orders of account are stored in.
+func JsonFilenameFor(account string) string {
+	if account == DefaultAccount {
+		return JsonFilename
+	}
+
+	return fmt.Sprintf("orders-%s.json", account)
+}
+
+func WriteOrders(account string, order *[]wolt.FullOrder) error {
 	b, err := json.MarshalIndent(order, "", " ")
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(fmt.Sprintf(JsonFilename), b, 0644)
+	err = os.WriteFile(JsonFilenameFor(account), b, 0644)
 
 	return err
 }
 
-func JsonExists() bool {
-	if _, err := os.Stat(JsonFilename); errors.Is(err, fs.ErrNotExist) {
+func JsonExists(account string) bool {
+	if _, err := os.Stat(JsonFilenameFor(account)); errors.Is(err, fs.ErrNotExist) {
 		return false
 	}
 
 	return true
 }
 
-func GetOrders() (*[]wolt.FullOrder, error) {
-	b, err := os.ReadFile(JsonFilename)
+// GetOrders returns the stored orders of account along with a problem for
+// every order that does not decode, which is left out.
+func GetOrders(account string) (*[]wolt.FullOrder, []wolt.ValidationError, error) {
+	b, err := os.ReadFile(JsonFilenameFor(account))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	var raw []json.RawMessage
+	err = json.Unmarshal(b, &raw)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	var orders []wolt.FullOrder
-	err = json.Unmarshal(b, &orders)
+	orders, problems := wolt.DecodeOrders(raw)
+
+	return orders, problems, nil
+}
+
+// GetStoredAccounts returns the accounts that have orders stored on disk.
+func GetStoredAccounts() ([]string, error) {
+	var accounts []string
+
+	if JsonExists(DefaultAccount) {
+		accounts = append(accounts, DefaultAccount)
+	}
+
+	matches, err := filepath.Glob("orders-*.json")
 	if err != nil {
 		return nil, err
 	}
 
-	return &orders, nil
+	for _, m := range matches {
+		accounts = append(accounts, strings.TrimSuffix(strings.TrimPrefix(m, "orders-"), ".json"))
+	}
+
+	sort.Strings(accounts)
+
+	return accounts, nil
 }
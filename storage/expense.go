@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"fmt"
+	"frederikhs/wolt/wolt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// ExpenseRule tags orders as business. An order is tagged when every field
+// set on any rule matches it.
+type ExpenseRule struct {
+	RuleId        int64      `db:"rule_id"`
+	OrderId       *string    `db:"order_id"`
+	Venue         *string    `db:"venue"`
+	DeliveryAlias *string    `db:"delivery_alias"`
+	DateFrom      *time.Time `db:"date_from"`
+	DateTo        *time.Time `db:"date_to"`
+}
+
+const createExpenseRule = `
+	CREATE TABLE IF NOT EXISTS expense_rule (
+		rule_id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id TEXT,
+		venue TEXT,
+		delivery_alias TEXT,
+		date_from DATETIME,
+		date_to DATETIME
+	)
+`
+
+func setupExpenses(db sqlx.Execer) {
+	sqlx.MustExec(db, `
+		CREATE VIEW view_business_order AS
+			SELECT * FROM view_wolt_order vwo
+			WHERE EXISTS (
+				SELECT 1 FROM expense_rule er
+				WHERE (er.order_id IS NULL OR er.order_id = vwo.order_id)
+				  AND (er.venue IS NULL OR er.venue = vwo.venue_id OR er.venue = vwo.venue_name)
+				  AND (er.delivery_alias IS NULL OR er.delivery_alias = vwo.delivery_alias)
+				  AND (er.date_from IS NULL OR julianday(vwo.payment_time) >= julianday(er.date_from))
+				  AND (er.date_to IS NULL OR julianday(vwo.payment_time) < julianday(er.date_to))
+			)
+	`)
+}
+
+func AddExpenseRule(db *sqlx.DB, rule ExpenseRule) (int64, error) {
+	if rule.OrderId == nil && rule.Venue == nil && rule.DeliveryAlias == nil && rule.DateFrom == nil && rule.DateTo == nil {
+		return 0, fmt.Errorf("an expense rule needs at least one condition")
+	}
+
+	res, err := db.NamedExec(`
+		INSERT INTO expense_rule (
+			order_id,
+			venue,
+			delivery_alias,
+			date_from,
+			date_to
+		) VALUES (
+			:order_id,
+			:venue,
+			:delivery_alias,
+			:date_from,
+			:date_to
+		)
+	`, rule)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+func DeleteExpenseRule(db *sqlx.DB, ruleId int64) error {
+	res, err := db.Exec("DELETE FROM expense_rule WHERE rule_id = ?", ruleId)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("no expense rule with id %d", ruleId)
+	}
+
+	return nil
+}
+
+func GetExpenseRules(db *sqlx.DB) (*[]ExpenseRule, error) {
+	var rows []ExpenseRule
+	err := db.Select(&rows, "SELECT * FROM expense_rule ORDER BY rule_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+type BusinessOrder struct {
+	AccountId     string     `db:"account_id"`
+	OrderId       string     `db:"order_id"`
+	OrderNumber   string     `db:"order_number"`
+	PaymentTime   *time.Time `db:"payment_time"`
+	VenueName     string     `db:"venue_name"`
+	DeliveryAlias string     `db:"delivery_alias"`
+	Currency      string     `db:"currency"`
+	DeliveryPrice int        `db:"delivery_price"`
+	ServiceFee    int        `db:"service_fee"`
+	Tip           int        `db:"tip"`
+	PaymentAmount int        `db:"payment_amount"`
+}
+
+func GetBusinessOrders(db *sqlx.DB, f Filter) (*[]BusinessOrder, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT account_id,
+			   order_id,
+			   order_number,
+			   payment_time,
+			   venue_name,
+			   delivery_alias,
+			   currency,
+			   delivery_price,
+			   service_fee,
+			   tip,
+			   payment_amount
+		FROM view_business_order
+		WHERE %s
+		ORDER BY payment_time
+	`, where)
+
+	var rows []BusinessOrder
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+func GetOrderItems(db *sqlx.DB, accountId, orderId string) (*[]wolt.SimpleItem, error) {
+	var rows []wolt.SimpleItem
+	err := db.Select(&rows, "SELECT * FROM wolt_order_item WHERE account_id = ? AND order_id = ? ORDER BY row_number", accountId, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
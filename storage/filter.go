@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+const DefaultStatus = "delivered"
+
+// Filter narrows the orders an aggregation is computed over. To is exclusive,
+// an empty Status means delivered orders only and every tag in Tags has to
+// be on either the order or its venue. An empty Account combines all
+// accounts.
+type Filter struct {
+	From        *time.Time
+	To          *time.Time
+	Venue       string
+	ProductLine string
+	Status      string
+	Tags        []string
+	Account     string
+}
+
+// where returns the conditions of the filter for view_wolt_order as sql
+// with placeholders and their arguments.
+func (f Filter) where() (string, []interface{}) {
+	status := f.Status
+	if status == "" {
+		status = DefaultStatus
+	}
+
+	conditions := []string{"status = ?"}
+	args := []interface{}{status}
+
+	if f.From != nil {
+		conditions = append(conditions, "julianday(payment_time) >= julianday(?)")
+		args = append(args, f.From.Format(time.RFC3339))
+	}
+
+	if f.To != nil {
+		conditions = append(conditions, "julianday(payment_time) < julianday(?)")
+		args = append(args, f.To.Format(time.RFC3339))
+	}
+
+	if f.Venue != "" {
+		conditions = append(conditions, "(venue_id = ? OR venue_name = ?)")
+		args = append(args, f.Venue, f.Venue)
+	}
+
+	if f.ProductLine != "" {
+		conditions = append(conditions, "venue_product_line = ?")
+		args = append(args, f.ProductLine)
+	}
+
+	if f.Account != "" {
+		conditions = append(conditions, "account_id = ?")
+		args = append(args, f.Account)
+	}
+
+	for _, tag := range f.Tags {
+		conditions = append(conditions, `(order_id IN (SELECT order_id FROM order_tag WHERE tag = ?)
+			OR venue_id IN (SELECT venue_id FROM venue_tag WHERE tag = ?))`)
+		args = append(args, tag, tag)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// groupOrder matches group orders, where the order is split between the
+// participants or paid by the host.
+const groupOrder = "(total_price_share > 0 OR is_host_paying)"
+
+// GroupOrderPeriod sums the group orders of a period in minor units. Share
+// is our part of the orders, Total their full price and PaidAsHost what we
+// paid on top of our share when hosting.
+type GroupOrderPeriod struct {
+	Period     string `json:"period" db:"period"`
+	Orders     int    `json:"orders" db:"orders"`
+	Share      int    `json:"share" db:"share"`
+	Total      int    `json:"total" db:"total"`
+	PaidAsHost int    `json:"paid_as_host" db:"paid_as_host"`
+}
+
+// GetGroupOrdersByMonth returns the group orders per local calendar month.
+func GetGroupOrdersByMonth(db *sqlx.DB, f Filter) (*[]GroupOrderPeriod, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT strftime('%%Y-%%m', payment_time, 'localtime') as period,
+			   COUNT(*) as orders,
+			   SUM(total_price_share) as share,
+			   SUM(total_price) as total,
+			   SUM(CASE WHEN is_host_paying THEN MAX(payment_amount - total_price_share, 0) ELSE 0 END) as paid_as_host
+		FROM view_wolt_order
+		WHERE %s AND %s
+		GROUP BY 1
+		ORDER BY 1
+	`, where, groupOrder)
+
+	var rows []GroupOrderPeriod
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
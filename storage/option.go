@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"sort"
+)
+
+// itemOptions labels the chosen options of every order item, like
+// "2 x Extra cheese, Large", ordered by name so the same choices always give
+// the same label.
+const itemOptions = `
+	item_options AS (
+		SELECT account_id, order_id, row_number, group_concat(label, ', ') as options
+		FROM (
+			SELECT account_id, order_id, row_number,
+				   CASE WHEN count > 1 THEN count || ' x ' || value_name ELSE value_name END as label
+			FROM wolt_order_item_option
+			ORDER BY account_id, order_id, row_number, value_name
+		)
+		GROUP BY 1, 2, 3
+	)`
+
+// AddonSpend splits the amount paid for items in a period into the items
+// themselves and their add-ons, in minor units.
+type AddonSpend struct {
+	Period string `json:"period" db:"period"`
+	Base   int    `json:"base" db:"base"`
+	Addons int    `json:"addons" db:"addons"`
+}
+
+// GetAddonSpendByMonth returns the spend on items and their add-ons per
+// local calendar month.
+func GetAddonSpendByMonth(db *sqlx.DB, f Filter) (*[]AddonSpend, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		WITH addons AS (
+			SELECT account_id, order_id, row_number, SUM(count * price) as price
+			FROM wolt_order_item_option
+			GROUP BY 1, 2, 3
+		)
+		SELECT strftime('%%Y-%%m', vwo.payment_time, 'localtime') as period,
+			   SUM(woi.count * woi.price) as base,
+			   coalesce(SUM(woi.count * a.price), 0) as addons
+		FROM (SELECT * FROM view_wolt_order WHERE %s) vwo
+		JOIN wolt_order_item woi ON woi.account_id = vwo.account_id AND woi.order_id = vwo.order_id
+		LEFT JOIN addons a ON a.account_id = woi.account_id AND a.order_id = woi.order_id AND a.row_number = woi.row_number
+		GROUP BY 1
+		ORDER BY 1
+	`, where)
+
+	var rows []AddonSpend
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+// Customization is an option value chosen for a dish and how many times.
+type Customization struct {
+	ItemName  string `json:"item_name" db:"item_name"`
+	ValueName string `json:"value_name" db:"value_name"`
+	Times     int    `json:"times" db:"times"`
+}
+
+// GetCommonCustomizations returns the option values chosen most often per
+// dish, most common first. A limit of 0 returns all of them.
+func GetCommonCustomizations(db *sqlx.DB, f Filter, limit int) (*[]Customization, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT woi.item_name, wooi.value_name, SUM(woi.count * wooi.count) as times
+		FROM (SELECT * FROM view_wolt_order WHERE %s) vwo
+		JOIN wolt_order_item woi ON woi.account_id = vwo.account_id AND woi.order_id = vwo.order_id
+		JOIN wolt_order_item_option wooi ON wooi.account_id = woi.account_id AND wooi.order_id = woi.order_id AND wooi.row_number = woi.row_number
+		GROUP BY woi.item_id, woi.item_name, wooi.value_id, wooi.value_name
+		ORDER BY times DESC, woi.item_name, wooi.value_name
+	`, where)
+
+	if limit > 0 {
+		sql += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	var rows []Customization
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+// ItemCombination is a dish with a particular choice of options at a venue,
+// with the number of orders it was in out of the orders at the venue and the
+// quantity ordered. Prices are of a single dish with its options, in minor
+// units.
+type ItemCombination struct {
+	VenueId     string `json:"venue_id" db:"venue_id"`
+	VenueName   string `json:"venue_name" db:"venue_name"`
+	ItemId      string `json:"item_id" db:"item_id"`
+	ItemName    string `json:"item_name" db:"item_name"`
+	Options     string `json:"options" db:"options"`
+	Orders      int    `json:"orders" db:"orders"`
+	VenueOrders int    `json:"venue_orders" db:"venue_orders"`
+	Quantity    int    `json:"quantity" db:"quantity"`
+	FirstPrice  int    `json:"first_price" db:"first_price"`
+	LastPrice   int    `json:"last_price" db:"last_price"`
+}
+
+// GetItemCombinations returns every dish and choice of options ordered,
+// grouped by venue with the most frequent first.
+func GetItemCombinations(db *sqlx.DB, f Filter) (*[]ItemCombination, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		WITH orders AS (
+			SELECT * FROM view_wolt_order WHERE %s
+		),
+		%s,
+		addons AS (
+			SELECT account_id, order_id, row_number, SUM(count * price) as price
+			FROM wolt_order_item_option
+			GROUP BY 1, 2, 3
+		),
+		lines AS (
+			SELECT o.venue_id,
+				   o.venue_name,
+				   o.account_id,
+				   o.order_id,
+				   woi.item_id,
+				   woi.item_name,
+				   coalesce(io.options, '') as options,
+				   woi.count,
+				   first_value(woi.price + coalesce(a.price, 0)) OVER combination_time as first_price,
+				   last_value(woi.price + coalesce(a.price, 0)) OVER (combination_time ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) as last_price
+			FROM orders o
+			JOIN wolt_order_item woi ON woi.account_id = o.account_id AND woi.order_id = o.order_id
+			LEFT JOIN item_options io ON io.account_id = woi.account_id AND io.order_id = woi.order_id AND io.row_number = woi.row_number
+			LEFT JOIN addons a ON a.account_id = woi.account_id AND a.order_id = woi.order_id AND a.row_number = woi.row_number
+			WINDOW combination_time AS (PARTITION BY o.venue_id, woi.item_id, io.options ORDER BY o.payment_time)
+		)
+		SELECT l.venue_id,
+			   l.venue_name,
+			   l.item_id,
+			   l.item_name,
+			   l.options,
+			   COUNT(DISTINCT l.account_id || l.order_id) as orders,
+			   (SELECT COUNT(*) FROM orders o WHERE o.venue_id = l.venue_id) as venue_orders,
+			   SUM(l.count) as quantity,
+			   MAX(l.first_price) as first_price,
+			   MAX(l.last_price) as last_price
+		FROM lines l
+		GROUP BY 1, 2, 3, 4, 5
+		ORDER BY l.venue_name, l.venue_id, orders DESC, quantity DESC, l.item_name, l.options
+	`, where, itemOptions)
+
+	var rows []ItemCombination
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+// GetUsualOrders returns the most frequent dish and choice of options of
+// every venue, for the venues with most orders of them first. A limit of 0
+// returns every venue.
+func GetUsualOrders(db *sqlx.DB, f Filter, limit int) (*[]ItemCombination, error) {
+	combinations, err := GetItemCombinations(db, f)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []ItemCombination
+	for i, c := range *combinations {
+		if i == 0 || (*combinations)[i-1].VenueId != c.VenueId {
+			rows = append(rows, c)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Orders > rows[j].Orders
+	})
+
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	return &rows, nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// paymentMethod names the payment method of an order by the name wolt shows
+// for it, falling back to its provider, type and id.
+const paymentMethod = `coalesce(nullif(payment_name, ''), payment_provider || ' ' || payment_type || ' ' || payment_method_id, 'Unknown')`
+
+// PaymentMethodSpend is the amount paid with a payment method in minor
+// units, over a period when Period is set.
+type PaymentMethodSpend struct {
+	Period        string `json:"period,omitempty" db:"period"`
+	PaymentMethod string `json:"payment_method" db:"payment_method"`
+	Orders        int    `json:"orders" db:"orders"`
+	Spend         int    `json:"spend" db:"spend"`
+}
+
+// GetPaymentMethodsBySpend returns the payment methods by the amount paid
+// with them, highest first.
+func GetPaymentMethodsBySpend(db *sqlx.DB, f Filter) (*[]PaymentMethodSpend, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT '' as period, %s as payment_method, COUNT(*) as orders, SUM(payment_amount) as spend
+		FROM view_wolt_order
+		WHERE %s
+		GROUP BY 2
+		ORDER BY spend DESC, payment_method
+	`, paymentMethod, where)
+
+	var rows []PaymentMethodSpend
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+// GetPaymentMethodSpendByMonth returns the amount paid with each payment
+// method per local calendar month.
+func GetPaymentMethodSpendByMonth(db *sqlx.DB, f Filter) (*[]PaymentMethodSpend, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT strftime('%%Y-%%m', payment_time, 'localtime') as period,
+			   %s as payment_method,
+			   COUNT(*) as orders,
+			   SUM(payment_amount) as spend
+		FROM view_wolt_order
+		WHERE %s
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`, paymentMethod, where)
+
+	var rows []PaymentMethodSpend
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+const createItemPrice = `
+	CREATE TABLE wolt_item_price (
+		venue_id TEXT,
+		item_id TEXT,
+		item_name TEXT,
+		price INT,
+		first_seen DATETIME,
+		last_seen DATETIME,
+		orders INT,
+		PRIMARY KEY (venue_id, item_id, first_seen)
+	)
+`
+
+// priceSegments returns sql selecting every period a dish kept its price at
+// a venue over the orders matching where, from the first to the last order
+// it was seen in at that price. A dish returning to an earlier price starts
+// a new period.
+func priceSegments(where string) string {
+	return fmt.Sprintf(`
+		WITH observations AS (
+			SELECT o.venue_id,
+				   o.venue_name,
+				   woi.item_id,
+				   woi.item_name,
+				   woi.price,
+				   o.payment_time,
+				   CASE WHEN woi.price = lag(woi.price) OVER dish THEN 0 ELSE 1 END as changed
+			FROM (SELECT * FROM view_wolt_order WHERE %s) o
+			JOIN wolt_order_item woi ON woi.account_id = o.account_id AND woi.order_id = o.order_id
+			WINDOW dish AS (PARTITION BY o.venue_id, woi.item_id ORDER BY julianday(o.payment_time))
+		),
+		segments AS (
+			SELECT *, SUM(changed) OVER (PARTITION BY venue_id, item_id ORDER BY julianday(payment_time) ROWS UNBOUNDED PRECEDING) as segment
+			FROM observations
+		)
+		SELECT venue_id,
+			   venue_name,
+			   item_id,
+			   MAX(item_name) as item_name,
+			   MAX(price) as price,
+			   MIN(payment_time) as first_seen,
+			   MAX(payment_time) as last_seen,
+			   COUNT(*) as orders
+		FROM segments
+		GROUP BY venue_id, venue_name, item_id, segment
+	`, where)
+}
+
+// SavePriceHistory rebuilds wolt_item_price from the stored orders, it has to
+// run after every account is saved.
+func SavePriceHistory(db sqlx.Execer) error {
+	where, args := Filter{}.where()
+
+	_, err := db.Exec(fmt.Sprintf(`
+		INSERT INTO wolt_item_price (venue_id, item_id, item_name, price, first_seen, last_seen, orders)
+		SELECT venue_id, item_id, item_name, price, first_seen, last_seen, orders
+		FROM (%s)
+	`, priceSegments(where)), args...)
+
+	return err
+}
+
+// ItemPrice is a price a dish had at a venue in minor units, seen from the
+// first to the last local date.
+type ItemPrice struct {
+	VenueId   string `json:"venue_id" db:"venue_id"`
+	VenueName string `json:"venue_name" db:"venue_name"`
+	ItemId    string `json:"item_id" db:"item_id"`
+	ItemName  string `json:"item_name" db:"item_name"`
+	Price     int    `json:"price" db:"price"`
+	FirstSeen string `json:"first_seen" db:"first_seen"`
+	LastSeen  string `json:"last_seen" db:"last_seen"`
+	Orders    int    `json:"orders" db:"orders"`
+}
+
+// GetPriceHistory returns the prices of every dish over the orders matching
+// f, ordered by venue and dish and then by when they were first seen.
+func GetPriceHistory(db *sqlx.DB, f Filter) (*[]ItemPrice, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT venue_id,
+			   venue_name,
+			   item_id,
+			   item_name,
+			   price,
+			   strftime('%%Y-%%m-%%d', first_seen, 'localtime') as first_seen,
+			   strftime('%%Y-%%m-%%d', last_seen, 'localtime') as last_seen,
+			   orders
+		FROM (%s)
+		ORDER BY venue_name, venue_id, item_name, item_id, julianday(first_seen)
+	`, priceSegments(where))
+
+	var rows []ItemPrice
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+// GetSavedItemPrices returns the price history kept by sync over every order,
+// ordered the same way as GetPriceHistory.
+func GetSavedItemPrices(db *sqlx.DB) (*[]ItemPrice, error) {
+	var rows []ItemPrice
+	err := db.Select(&rows, `
+		SELECT wip.venue_id,
+			   coalesce(wv.venue_name, '') as venue_name,
+			   wip.item_id,
+			   wip.item_name,
+			   wip.price,
+			   strftime('%Y-%m-%d', wip.first_seen, 'localtime') as first_seen,
+			   strftime('%Y-%m-%d', wip.last_seen, 'localtime') as last_seen,
+			   wip.orders
+		FROM wolt_item_price wip
+		LEFT JOIN wolt_venue wv ON wv.venue_id = wip.venue_id
+		ORDER BY venue_name, wip.venue_id, wip.item_name, wip.item_id, julianday(wip.first_seen)
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+// ItemPurchase is the quantity of a dish bought at a price in a local
+// calendar month.
+type ItemPurchase struct {
+	Month    string `json:"month" db:"month"`
+	VenueId  string `json:"venue_id" db:"venue_id"`
+	ItemId   string `json:"item_id" db:"item_id"`
+	Quantity int    `json:"quantity" db:"quantity"`
+	Price    int    `json:"price" db:"price"`
+}
+
+// GetItemPurchases returns every dish bought in the orders matching f, in the
+// order they were bought.
+func GetItemPurchases(db *sqlx.DB, f Filter) (*[]ItemPurchase, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT strftime('%%Y-%%m', vwo.payment_time, 'localtime') as month,
+			   vwo.venue_id,
+			   woi.item_id,
+			   woi.count as quantity,
+			   woi.price
+		FROM (SELECT * FROM view_wolt_order WHERE %s) vwo
+		JOIN wolt_order_item woi ON woi.account_id = vwo.account_id AND woi.order_id = vwo.order_id
+		ORDER BY julianday(vwo.payment_time), woi.row_number
+	`, where)
+
+	var rows []ItemPurchase
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
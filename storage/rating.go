@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// createRating holds ratings of whole orders, with an empty item_id, and of
+// single dishes of an order.
+const createRating = `
+	CREATE TABLE IF NOT EXISTS rating (
+		order_id TEXT NOT NULL,
+		item_id TEXT NOT NULL DEFAULT '',
+		rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+		review TEXT,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (order_id, item_id)
+	)
+`
+
+type Rating struct {
+	OrderId   string    `db:"order_id"`
+	ItemId    string    `db:"item_id"`
+	Rating    int       `db:"rating"`
+	Review    string    `db:"review"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// SaveRating stores the rating, replacing an earlier rating of the same
+// order or dish.
+func SaveRating(db *sqlx.DB, r Rating) error {
+	if r.Rating < 1 || r.Rating > 5 {
+		return fmt.Errorf("rating must be between 1 and 5, got %d", r.Rating)
+	}
+
+	r.CreatedAt = time.Now()
+
+	_, err := db.NamedExec(`
+		INSERT OR REPLACE INTO rating (order_id, item_id, rating, review, created_at)
+		VALUES (:order_id, :item_id, :rating, :review, :created_at)
+	`, r)
+
+	return err
+}
+
+// ResolveItemId returns the id of the item with the given id or name in the
+// order.
+func ResolveItemId(db *sqlx.DB, orderId, item string) (string, error) {
+	var ids []string
+	err := db.Select(&ids, "SELECT DISTINCT item_id FROM wolt_order_item WHERE order_id = ? AND (item_id = ? OR item_name = ?)", orderId, item, item)
+	if err != nil {
+		return "", err
+	}
+
+	if len(ids) != 1 {
+		return "", fmt.Errorf("order %s has no single item with id or name %q", orderId, item)
+	}
+
+	return ids[0], nil
+}
+
+type RatedOrder struct {
+	OrderId         string  `json:"order_id" db:"order_id"`
+	VenueName       string  `json:"venue_name" db:"venue_name"`
+	Rating          int     `json:"rating" db:"rating"`
+	Price           int     `json:"price" db:"price"`
+	DeliveryMinutes float64 `json:"delivery_minutes" db:"delivery_minutes"`
+}
+
+// GetRatedOrders returns the orders rated as a whole with their price in
+// major units and the minutes from payment to delivery.
+func GetRatedOrders(db *sqlx.DB, f Filter) (*[]RatedOrder, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT vwo.order_id,
+			   vwo.venue_name,
+			   r.rating,
+			   vwo.payment_amount / 100 as price,
+			   coalesce((julianday(vwo.delivery_time) - julianday(vwo.payment_time)) * 1440, 0) as delivery_minutes
+		FROM (SELECT * FROM view_wolt_order WHERE %s) vwo
+		JOIN rating r ON r.order_id = vwo.order_id AND r.item_id = ''
+		ORDER BY vwo.payment_time
+	`, where)
+
+	var rows []RatedOrder
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+type VenueRating struct {
+	VenueName string  `json:"venue_name" db:"venue_name"`
+	Rating    float64 `json:"rating" db:"rating"`
+	Ratings   int     `json:"ratings" db:"ratings"`
+	AvgSpend  float64 `json:"avg_spend" db:"avg_spend"`
+	// Value is the average rating per 100 spent on an average order.
+	Value float64 `json:"value" db:"value"`
+}
+
+// GetVenueRatings returns the average rating of every rated venue, counting
+// both order and dish ratings, sorted by rating in ascending order.
+func GetVenueRatings(db *sqlx.DB, f Filter) (*[]VenueRating, error) {
+	return getVenueRatings(db, f, "rating")
+}
+
+// GetVenuesByValue ranks the rated venues by their rating per money spent,
+// in ascending order, keeping the limit best.
+func GetVenuesByValue(db *sqlx.DB, f Filter, limit int) (*[]VenueRating, error) {
+	rows, err := getVenueRatings(db, f, "value")
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(*rows) > limit {
+		top := (*rows)[len(*rows)-limit:]
+		rows = &top
+	}
+
+	return rows, nil
+}
+
+func getVenueRatings(db *sqlx.DB, f Filter, orderBy string) (*[]VenueRating, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		WITH filtered AS (SELECT order_id, venue_id, venue_name, payment_amount
+						  FROM view_wolt_order
+						  WHERE %s),
+			 spend AS (SELECT venue_id, AVG(payment_amount) / 100.0 as avg_spend
+					   FROM filtered
+					   GROUP BY venue_id)
+		SELECT f.venue_name,
+			   AVG(r.rating) as rating,
+			   COUNT(*) as ratings,
+			   s.avg_spend,
+			   AVG(r.rating) / s.avg_spend * 100 as value
+		FROM filtered f
+		JOIN rating r ON r.order_id = f.order_id
+		JOIN spend s ON s.venue_id = f.venue_id
+		WHERE s.avg_spend > 0
+		GROUP BY f.venue_id
+		ORDER BY %s
+	`, where, orderBy)
+
+	var rows []VenueRating
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
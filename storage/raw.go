@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"frederikhs/wolt/wolt"
+	"github.com/jmoiron/sqlx"
+	"io"
+	"time"
+)
+
+// createRawOrder archives every order as the api sent it, gzipped, so the
+// typed tables can be rebuilt when FullOrder learns new fields. Orders only
+// known from an orders json written before the archive existed are stored as
+// re-marshalled from FullOrder, with source json.
+const createRawOrder = `
+	CREATE TABLE IF NOT EXISTS raw_order (
+		account_id TEXT NOT NULL,
+		order_id TEXT NOT NULL,
+		fetched_at DATETIME,
+		source TEXT,
+		data BLOB,
+		PRIMARY KEY (account_id, order_id)
+	)
+`
+
+const (
+	RawSourceApi  = "api"
+	RawSourceJson = "json"
+)
+
+type RawOrder struct {
+	AccountId string    `db:"account_id"`
+	OrderId   string    `db:"order_id"`
+	FetchedAt time.Time `db:"fetched_at"`
+	Source    string    `db:"source"`
+	Data      []byte    `db:"data"`
+}
+
+// SaveRawOrders archives orders fetched from the api, replacing earlier
+// versions of them. Orders without an id cannot be archived, they are left
+// out and returned as problems that skip them.
+func SaveRawOrders(db *sqlx.DB, account string, orders []json.RawMessage) ([]wolt.ValidationError, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var problems []wolt.ValidationError
+	now := time.Now()
+	for _, o := range orders {
+		var id struct {
+			OrderId string `json:"order_id"`
+		}
+		err = json.Unmarshal(o, &id)
+		if err != nil {
+			problems = append(problems, wolt.ValidationError{Field: "order", Problem: fmt.Sprintf("cannot be archived: %s", err), Skip: true})
+			continue
+		}
+
+		if id.OrderId == "" {
+			problems = append(problems, wolt.ValidationError{Field: "order_id", Problem: "is missing", Skip: true})
+			continue
+		}
+
+		data, err := compress(o)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec("INSERT OR REPLACE INTO raw_order (account_id, order_id, fetched_at, source, data) VALUES (?, ?, ?, ?, ?)",
+			account, id.OrderId, now, RawSourceApi, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return problems, tx.Commit()
+}
+
+// ArchiveOrders archives the orders that are not archived yet, for orders
+// that were stored before the archive existed.
+func ArchiveOrders(db *sqlx.DB, account string, orders []wolt.FullOrder) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, o := range orders {
+		b, err := json.Marshal(o)
+		if err != nil {
+			return err
+		}
+
+		data, err := compress(b)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT OR IGNORE INTO raw_order (account_id, order_id, fetched_at, source, data) VALUES (?, ?, ?, ?, ?)",
+			account, o.OrderId, now, RawSourceJson, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetRawOrders returns every archived order, uncompressed, by account.
+func GetRawOrders(db *sqlx.DB) (*[]RawOrder, error) {
+	var rows []RawOrder
+	err := db.Select(&rows, "SELECT * FROM raw_order ORDER BY account_id, order_id")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range rows {
+		rows[i].Data, err = decompress(r.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &rows, nil
+}
+
+func compress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+
+	_, err := w.Write(b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decompress(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+const createOrderTag = `
+	CREATE TABLE IF NOT EXISTS order_tag (
+		order_id TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (order_id, tag)
+	)
+`
+
+const createVenueTag = `
+	CREATE TABLE IF NOT EXISTS venue_tag (
+		venue_id TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (venue_id, tag)
+	)
+`
+
+const createNote = `
+	CREATE TABLE IF NOT EXISTS note (
+		note_id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id TEXT,
+		venue_id TEXT,
+		text TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	)
+`
+
+type Tag struct {
+	Kind string `db:"kind"`
+	Id   string `db:"id"`
+	Name string `db:"name"`
+	Tag  string `db:"tag"`
+}
+
+type Note struct {
+	NoteId    int64     `db:"note_id"`
+	OrderId   *string   `db:"order_id"`
+	VenueId   *string   `db:"venue_id"`
+	Text      string    `db:"text"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// ResolveVenueId returns the id of the venue with the given id or name, a
+// name the venue had before it was renamed is used when no venue has it now.
+func ResolveVenueId(db *sqlx.DB, venue string) (string, error) {
+	var ids []string
+	err := db.Select(&ids, "SELECT venue_id FROM wolt_venue WHERE venue_id = ? OR venue_name = ?", venue, venue)
+	if err != nil {
+		return "", err
+	}
+
+	if len(ids) == 0 {
+		err = db.Select(&ids, "SELECT DISTINCT venue_id FROM wolt_venue_history WHERE venue_name = ?", venue)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no venue with id or name %q", venue)
+	}
+
+	if len(ids) > 1 {
+		return "", fmt.Errorf("venue name %q is ambiguous, use the venue id", venue)
+	}
+
+	return ids[0], nil
+}
+
+// CheckOrderId returns an error when no stored order has the id.
+func CheckOrderId(db *sqlx.DB, orderId string) error {
+	var n int
+	err := db.Get(&n, "SELECT COUNT(*) FROM wolt_order WHERE order_id = ?", orderId)
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("no order with id %q", orderId)
+	}
+
+	return nil
+}
+
+func AddOrderTag(db *sqlx.DB, orderId, tag string) error {
+	_, err := db.Exec("INSERT OR IGNORE INTO order_tag (order_id, tag) VALUES (?, ?)", orderId, tag)
+	return err
+}
+
+func RemoveOrderTag(db *sqlx.DB, orderId, tag string) error {
+	_, err := db.Exec("DELETE FROM order_tag WHERE order_id = ? AND tag = ?", orderId, tag)
+	return err
+}
+
+func AddVenueTag(db *sqlx.DB, venueId, tag string) error {
+	_, err := db.Exec("INSERT OR IGNORE INTO venue_tag (venue_id, tag) VALUES (?, ?)", venueId, tag)
+	return err
+}
+
+func RemoveVenueTag(db *sqlx.DB, venueId, tag string) error {
+	_, err := db.Exec("DELETE FROM venue_tag WHERE venue_id = ? AND tag = ?", venueId, tag)
+	return err
+}
+
+func GetTags(db *sqlx.DB) (*[]Tag, error) {
+	var rows []Tag
+	err := db.Select(&rows, `
+		SELECT 'order' as kind, ot.order_id as id, coalesce(wv.venue_name, '') as name, ot.tag
+		FROM order_tag ot
+		LEFT JOIN wolt_order wo ON wo.order_id = ot.order_id
+		LEFT JOIN wolt_venue wv ON wv.venue_id = wo.venue_id
+		UNION ALL
+		SELECT 'venue' as kind, vt.venue_id as id, coalesce(wv.venue_name, '') as name, vt.tag
+		FROM venue_tag vt
+		LEFT JOIN wolt_venue wv ON wv.venue_id = vt.venue_id
+		ORDER BY tag, kind, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+func AddNote(db *sqlx.DB, note Note) (int64, error) {
+	if (note.OrderId == nil) == (note.VenueId == nil) {
+		return 0, fmt.Errorf("a note belongs to either an order or a venue")
+	}
+
+	note.CreatedAt = time.Now()
+
+	res, err := db.NamedExec(`
+		INSERT INTO note (order_id, venue_id, text, created_at)
+		VALUES (:order_id, :venue_id, :text, :created_at)
+	`, note)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+func DeleteNote(db *sqlx.DB, noteId int64) error {
+	res, err := db.Exec("DELETE FROM note WHERE note_id = ?", noteId)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("no note with id %d", noteId)
+	}
+
+	return nil
+}
+
+func GetNotes(db *sqlx.DB) (*[]Note, error) {
+	var rows []Note
+	err := db.Select(&rows, "SELECT * FROM note ORDER BY created_at, note_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
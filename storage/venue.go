@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"fmt"
+	"frederikhs/wolt/wolt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+	"time"
+)
+
+const createVenueHistory = `
+	CREATE TABLE wolt_venue_history (
+		venue_id TEXT NOT NULL,
+		snapshot_key TEXT NOT NULL,
+		venue_name TEXT,
+		venue_address TEXT,
+		venue_full_address TEXT,
+		venue_country TEXT,
+		venue_phone TEXT,
+		venue_timezone TEXT,
+		venue_product_line TEXT,
+		venue_coordinate_x TEXT,
+		venue_coordinate_y TEXT,
+		venue_url TEXT,
+		main_image TEXT,
+		main_image_blurhash TEXT,
+		list_image TEXT,
+		list_image_blurhash TEXT,
+		first_seen DATETIME,
+		last_seen DATETIME,
+		orders INT,
+		PRIMARY KEY (venue_id, snapshot_key)
+	)
+`
+
+// VenueSnapshot is a venue as it was described in the orders placed at it
+// from the first to the last time it was seen. Its images are the ones of the
+// last order, a new image does not make a new snapshot.
+type VenueSnapshot struct {
+	wolt.SimpleVenue
+	SnapshotKey string     `json:"-" db:"snapshot_key"`
+	FirstSeen   *time.Time `json:"first_seen" db:"first_seen"`
+	LastSeen    *time.Time `json:"last_seen" db:"last_seen"`
+	Orders      int        `json:"orders" db:"orders"`
+}
+
+func newVenueSnapshot(v wolt.SimpleVenue, seen *time.Time) VenueSnapshot {
+	key := strings.Join([]string{
+		v.VenueName,
+		v.VenueAddress,
+		v.VenueFullAddress,
+		v.VenueCountry,
+		v.VenuePhone,
+		v.VenueTimezone,
+		v.VenueProductLine,
+		coordinate(v.VenueCoordinateX),
+		coordinate(v.VenueCoordinateY),
+		v.VenueUrl,
+	}, "\x1f")
+
+	return VenueSnapshot{
+		SimpleVenue: v,
+		SnapshotKey: key,
+		FirstSeen:   seen,
+		LastSeen:    seen,
+		Orders:      1,
+	}
+}
+
+func coordinate(c *float64) string {
+	if c == nil {
+		return ""
+	}
+
+	return fmt.Sprint(*c)
+}
+
+// addVenueSnapshot merges the venue of an order seen at the given time into
+// snapshots, keyed by venue and snapshot.
+func addVenueSnapshot(snapshots map[[2]string]*VenueSnapshot, v wolt.SimpleVenue, seen *time.Time) {
+	s := newVenueSnapshot(v, seen)
+	existing, ok := snapshots[[2]string{s.VenueId, s.SnapshotKey}]
+	if !ok {
+		snapshots[[2]string{s.VenueId, s.SnapshotKey}] = &s
+		return
+	}
+
+	existing.Orders++
+	if seen == nil {
+		return
+	}
+	if existing.FirstSeen == nil || seen.Before(*existing.FirstSeen) {
+		existing.FirstSeen = seen
+	}
+	if existing.LastSeen == nil || seen.After(*existing.LastSeen) {
+		existing.SimpleVenue = s.SimpleVenue
+		existing.LastSeen = seen
+	}
+}
+
+// saveVenueSnapshots merges snapshots into wolt_venue_history, where other
+// accounts may have seen the same snapshot, and points wolt_venue at the
+// latest snapshot of every venue.
+func saveVenueSnapshots(db sqlx.Ext, snapshots map[[2]string]*VenueSnapshot) error {
+	for _, s := range snapshots {
+		_, err := sqlx.NamedExec(db, `
+			INSERT INTO wolt_venue_history (
+				venue_id,
+				snapshot_key,
+				venue_name,
+				venue_address,
+				venue_full_address,
+				venue_country,
+				venue_phone,
+				venue_timezone,
+				venue_product_line,
+				venue_coordinate_x,
+				venue_coordinate_y,
+				venue_url,
+				main_image,
+				main_image_blurhash,
+				list_image,
+				list_image_blurhash,
+				first_seen,
+				last_seen,
+				orders
+			) VALUES (
+				:venue_id,
+				:snapshot_key,
+				:venue_name,
+				:venue_address,
+				:venue_full_address,
+				:venue_country,
+				:venue_phone,
+				:venue_timezone,
+				:venue_product_line,
+				:venue_coordinate_x,
+				:venue_coordinate_y,
+				:venue_url,
+				:main_image,
+				:main_image_blurhash,
+				:list_image,
+				:list_image_blurhash,
+				:first_seen,
+				:last_seen,
+				:orders
+			) ON CONFLICT (venue_id, snapshot_key) DO UPDATE SET
+				main_image = CASE WHEN last_seen IS NULL OR julianday(excluded.last_seen) > julianday(last_seen) THEN excluded.main_image ELSE main_image END,
+				main_image_blurhash = CASE WHEN last_seen IS NULL OR julianday(excluded.last_seen) > julianday(last_seen) THEN excluded.main_image_blurhash ELSE main_image_blurhash END,
+				list_image = CASE WHEN last_seen IS NULL OR julianday(excluded.last_seen) > julianday(last_seen) THEN excluded.list_image ELSE list_image END,
+				list_image_blurhash = CASE WHEN last_seen IS NULL OR julianday(excluded.last_seen) > julianday(last_seen) THEN excluded.list_image_blurhash ELSE list_image_blurhash END,
+				first_seen = CASE WHEN first_seen IS NULL OR julianday(excluded.first_seen) < julianday(first_seen) THEN excluded.first_seen ELSE first_seen END,
+				last_seen = CASE WHEN last_seen IS NULL OR julianday(excluded.last_seen) > julianday(last_seen) THEN excluded.last_seen ELSE last_seen END,
+				orders = orders + excluded.orders
+		`, s)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO wolt_venue (
+			venue_id,
+			venue_name,
+			venue_address,
+			venue_full_address,
+			venue_country,
+			venue_phone,
+			venue_timezone,
+			venue_product_line,
+			venue_coordinate_x,
+			venue_coordinate_y,
+			venue_url,
+			main_image,
+			main_image_blurhash,
+			list_image,
+			list_image_blurhash
+		)
+		SELECT venue_id,
+			   venue_name,
+			   venue_address,
+			   venue_full_address,
+			   venue_country,
+			   venue_phone,
+			   venue_timezone,
+			   venue_product_line,
+			   venue_coordinate_x,
+			   venue_coordinate_y,
+			   venue_url,
+			   main_image,
+			   main_image_blurhash,
+			   list_image,
+			   list_image_blurhash
+		FROM (
+			SELECT *, row_number() OVER (PARTITION BY venue_id ORDER BY julianday(last_seen) DESC, orders DESC) as latest
+			FROM wolt_venue_history
+		)
+		WHERE latest = 1
+	`)
+
+	return err
+}
+
+// GetVenueHistory returns every snapshot of every venue, the snapshots of a
+// venue ordered by when they were first seen.
+func GetVenueHistory(db *sqlx.DB) (*[]VenueSnapshot, error) {
+	var rows []VenueSnapshot
+	err := db.Select(&rows, `
+		SELECT *
+		FROM wolt_venue_history
+		ORDER BY venue_id, julianday(first_seen), julianday(last_seen)
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
+
+// VenueCard is a venue with the details shown on its card and the number of
+// orders placed at it.
+type VenueCard struct {
+	VenueId           string `json:"venue_id" db:"venue_id"`
+	VenueName         string `json:"venue_name" db:"venue_name"`
+	VenueAddress      string `json:"venue_address" db:"venue_address"`
+	VenueFullAddress  string `json:"venue_full_address" db:"venue_full_address"`
+	VenueCountry      string `json:"venue_country" db:"venue_country"`
+	VenuePhone        string `json:"venue_phone" db:"venue_phone"`
+	VenueTimezone     string `json:"venue_timezone" db:"venue_timezone"`
+	VenueProductLine  string `json:"venue_product_line" db:"venue_product_line"`
+	VenueUrl          string `json:"venue_url" db:"venue_url"`
+	ListImage         string `json:"list_image" db:"list_image"`
+	ListImageBlurhash string `json:"list_image_blurhash" db:"list_image_blurhash"`
+	Orders            int    `json:"orders" db:"orders"`
+}
+
+// GetVenueCards returns the venues of the orders matching f, most ordered
+// from first. A venue without a list image gets its main image. A limit of 0
+// returns every venue.
+func GetVenueCards(db *sqlx.DB, f Filter, limit int) (*[]VenueCard, error) {
+	where, args := f.where()
+	sql := fmt.Sprintf(`
+		SELECT wv.venue_id,
+			   coalesce(wv.venue_name, '') as venue_name,
+			   coalesce(wv.venue_address, '') as venue_address,
+			   coalesce(wv.venue_full_address, '') as venue_full_address,
+			   coalesce(wv.venue_country, '') as venue_country,
+			   coalesce(wv.venue_phone, '') as venue_phone,
+			   coalesce(wv.venue_timezone, '') as venue_timezone,
+			   coalesce(wv.venue_product_line, '') as venue_product_line,
+			   coalesce(wv.venue_url, '') as venue_url,
+			   coalesce(nullif(wv.list_image, ''), wv.main_image, '') as list_image,
+			   coalesce(nullif(wv.list_image_blurhash, ''), wv.main_image_blurhash, '') as list_image_blurhash,
+			   COUNT(*) as orders
+		FROM (SELECT * FROM view_wolt_order WHERE %s) vwo
+		JOIN wolt_venue wv ON wv.venue_id = vwo.venue_id
+		GROUP BY wv.venue_id
+		ORDER BY orders DESC, wv.venue_name
+	`, where)
+
+	if limit > 0 {
+		sql += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	var rows []VenueCard
+	err := db.Select(&rows, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows, nil
+}
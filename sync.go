@@ -0,0 +1,329 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/budget"
+	"frederikhs/wolt/config"
+	"frederikhs/wolt/notify"
+	"frederikhs/wolt/storage"
+	"frederikhs/wolt/wolt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// GetOrders returns every order along with the ones that were not stored in
+// the json before, none when nothing was stored before. Pages are fetched
+// newest first until one holds an order that is already stored, which then
+// gets replaced by the fetched version. Offline reuses the stored orders
+// without fetching. Fetched orders are archived in the db as the api sent
+// them. The problems returned are those of the orders missing from the
+// archive, orders of the json that do not decode and fetched orders without
+// an id, the problems of the archived orders are found when it is decoded.
+func GetOrders(db *sqlx.DB, client *wolt.Client, account string, offline bool) (*[]wolt.FullOrder, []wolt.FullOrder, []wolt.ValidationError, error) {
+	var stored []wolt.FullOrder
+	var problems []wolt.ValidationError
+
+	if storage.JsonExists(account) {
+		orders, p, err := storage.GetOrders(account)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		stored = *orders
+		problems = p
+
+		err = storage.ArchiveOrders(db, account, stored)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if offline {
+			log.Printf("%s did exist, reusing\n", storage.JsonFilenameFor(account))
+			return &stored, nil, problems, nil
+		}
+
+		log.Printf("%s did exist with %d orders, fetching new orders\n", storage.JsonFilenameFor(account), len(stored))
+	} else {
+		if offline {
+			return nil, nil, nil, fmt.Errorf("%s does not exist, sync without -offline first", storage.JsonFilenameFor(account))
+		}
+
+		log.Printf("%s did not exists, fetching orders\n", storage.JsonFilenameFor(account))
+	}
+
+	// On the first sync of an account every order would be new, only the
+	// orders fetched after that are worth notifying about.
+	firstSync := len(stored) == 0
+
+	known := map[string]bool{}
+	for _, o := range stored {
+		known[o.OrderId] = true
+	}
+
+	var fetched []wolt.FullOrder
+	var newOrders []wolt.FullOrder
+
+	limit := 50
+	skip := 0
+	done := false
+
+	for !done {
+		raw, err := client.RequestRawOrders(limit, skip)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		p, err := storage.SaveRawOrders(db, account, raw)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		problems = append(problems, p...)
+
+		o, _ := wolt.DecodeOrders(raw)
+
+		// Paging follows what the api sent, orders that do not decode still
+		// take up their place in the page.
+		fetched = append(fetched, *o...)
+		if len(raw) == 0 {
+			done = true
+		}
+
+		for _, order := range *o {
+			if known[order.OrderId] {
+				done = true
+			} else {
+				newOrders = append(newOrders, order)
+			}
+		}
+
+		skip = skip + len(raw)
+		log.Printf("requested orders, got %d back\n", len(raw))
+		time.Sleep(time.Second)
+	}
+
+	if firstSync {
+		newOrders = nil
+	}
+
+	refetched := map[string]bool{}
+	for _, o := range fetched {
+		refetched[o.OrderId] = true
+	}
+
+	orders := fetched
+	for _, o := range stored {
+		if !refetched[o.OrderId] {
+			orders = append(orders, o)
+		}
+	}
+
+	err := storage.WriteOrders(account, &orders)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &orders, newOrders, problems, nil
+}
+
+// syncTokens returns the tokens of the accounts to fetch. A token on the
+// command line is for the default account, or the one named by -account,
+// otherwise every configured account is fetched.
+func syncTokens(c *config.Config, token, account string, offline bool) (map[string]string, error) {
+	tokens := map[string]string{}
+
+	if offline {
+		return tokens, nil
+	}
+
+	if token != "" {
+		if account == "" {
+			account = storage.DefaultAccount
+		}
+		tokens[account] = token
+		return tokens, nil
+	}
+
+	for _, a := range c.Accounts {
+		if account != "" && a.Name != account {
+			continue
+		}
+
+		t, err := a.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		tokens[a.Name] = t
+	}
+
+	if account != "" && len(tokens) == 0 {
+		return nil, fmt.Errorf("account %s is not configured", account)
+	}
+
+	return tokens, nil
+}
+
+// logProblems logs the validation problems of the orders of account and how
+// many orders were skipped or stored without some of their fields.
+func logProblems(account string, problems []wolt.ValidationError) {
+	if len(problems) == 0 {
+		return
+	}
+
+	skipped := map[string]bool{}
+	partial := map[string]bool{}
+	for _, p := range problems {
+		log.Println(p.Error())
+		if p.Skip {
+			skipped[p.OrderId] = true
+		} else {
+			partial[p.OrderId] = true
+		}
+	}
+
+	for id := range skipped {
+		delete(partial, id)
+	}
+
+	log.Printf("account %s: skipped %d orders, stored %d orders partially\n", account, len(skipped), len(partial))
+}
+
+// rebuild replaces the synced tables with the orders of every account in one
+// transaction, so a failure leaves wolt.db as it was. Problems are those of
+// the orders of every account that did not decode.
+func rebuild(db *sqlx.DB, accounts []string, orders map[string][]wolt.FullOrder, problems map[string][]wolt.ValidationError) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	storage.Setup(tx)
+
+	for _, a := range accounts {
+		o := orders[a]
+		p, err := storage.SaveOrders(tx, a, &o)
+		if err != nil {
+			return err
+		}
+		logProblems(a, append(problems[a], p...))
+
+		log.Printf("stored %d orders of account %s\n", len(o), a)
+	}
+
+	err = storage.SavePriceHistory(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func Sync(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	offline := fs.Bool("offline", false, "rebuild wolt.db from the stored orders without fetching")
+	account := fs.String("account", "", "only fetch this account, the others are rebuilt from their stored orders")
+	configPath := fs.String("config", config.DefaultPath, "config file with accounts, budgets and notifications")
+	notifyFlag := fs.Bool("notify", true, "send the configured notifications about new orders")
+	fs.Parse(args)
+
+	c, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 1 || (fs.NArg() == 0 && !*offline && len(c.Accounts) == 0) {
+		fmt.Printf("usage: %s sync [-offline] [-account NAME] [-notify=false] [-config wolt.yaml] [TOKEN]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	tokens, err := syncTokens(c, fs.Arg(0), *account, *offline)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := storage.GetStoredAccounts()
+	if err != nil {
+		return err
+	}
+
+	for a := range tokens {
+		if !storage.JsonExists(a) {
+			accounts = append(accounts, a)
+		}
+	}
+
+	if len(accounts) == 0 {
+		return fmt.Errorf("%s does not exist, sync without -offline first", storage.JsonFilename)
+	}
+
+	sort.Strings(accounts)
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	jsonProblems := map[string][]wolt.ValidationError{}
+	var newOrders []wolt.FullOrder
+	for _, a := range accounts {
+		token, fetch := tokens[a]
+
+		o, n, p, err := GetOrders(db, wolt.NewClient(token), a, !fetch)
+		if err != nil {
+			return err
+		}
+
+		jsonProblems[a] = p
+		log.Printf("fetched %d orders of account %s, %d new\n", len(*o), a, len(n))
+		newOrders = append(newOrders, n...)
+	}
+
+	// The tables are built from the archive like reparse does, the json has
+	// lost the fields FullOrder does not know.
+	archived, orders, problems, err := archivedOrders(db)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range accounts {
+		if _, ok := orders[a]; !ok {
+			archived = append(archived, a)
+		}
+		problems[a] = append(jsonProblems[a], problems[a]...)
+	}
+	sort.Strings(archived)
+
+	err = rebuild(db, archived, orders, problems)
+	if err != nil {
+		return err
+	}
+
+	notifiers := notify.FromConfig(c.Notify)
+	if !*notifyFlag || len(notifiers) == 0 {
+		return nil
+	}
+
+	periods, err := budget.Current(db, c.Budget, storage.Filter{}, time.Now())
+	if err != nil {
+		return err
+	}
+
+	summary := notify.NewSummary(newOrders, periods)
+	if !summary.Worth() {
+		return nil
+	}
+
+	for _, n := range notifiers {
+		err = n.Notify(summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/storage"
+	"os"
+	"strconv"
+)
+
+func tagUsage() {
+	fmt.Printf("usage: %s tag <command> [arguments]\n\n", os.Args[0])
+	fmt.Println("commands:")
+	fmt.Println("  add (-order ID | -venue NAME) <TAG>       tag an order or a venue")
+	fmt.Println("  remove (-order ID | -venue NAME) <TAG>    remove a tag from an order or a venue")
+	fmt.Println("  list                                      list every tag")
+	os.Exit(1)
+}
+
+func Tag(args []string) error {
+	if len(args) < 1 {
+		tagUsage()
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "add", "remove":
+		fs := flag.NewFlagSet("tag "+args[0], flag.ExitOnError)
+		orderId := fs.String("order", "", "order id")
+		venue := fs.String("venue", "", "venue name or id")
+		fs.Parse(args[1:])
+
+		if fs.NArg() != 1 || (*orderId == "") == (*venue == "") {
+			tagUsage()
+		}
+		tag := fs.Arg(0)
+
+		if *orderId != "" {
+			if args[0] == "add" {
+				err = storage.CheckOrderId(db, *orderId)
+				if err != nil {
+					return err
+				}
+
+				return storage.AddOrderTag(db, *orderId, tag)
+			}
+			return storage.RemoveOrderTag(db, *orderId, tag)
+		}
+
+		venueId, err := storage.ResolveVenueId(db, *venue)
+		if err != nil {
+			return err
+		}
+
+		if args[0] == "add" {
+			return storage.AddVenueTag(db, venueId, tag)
+		}
+		return storage.RemoveVenueTag(db, venueId, tag)
+	case "list":
+		tags, err := storage.GetTags(db)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range *tags {
+			fmt.Printf("%s\t%s\t%s\t%s\n", t.Tag, t.Kind, t.Id, t.Name)
+		}
+	default:
+		tagUsage()
+	}
+
+	return nil
+}
+
+func noteUsage() {
+	fmt.Printf("usage: %s note <command> [arguments]\n\n", os.Args[0])
+	fmt.Println("commands:")
+	fmt.Println("  add (-order ID | -venue NAME) <TEXT>    add a note to an order or a venue")
+	fmt.Println("  remove <NOTE_ID>                        remove a note")
+	fmt.Println("  list                                    list every note")
+	os.Exit(1)
+}
+
+func Note(args []string) error {
+	if len(args) < 1 {
+		noteUsage()
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("note add", flag.ExitOnError)
+		orderId := fs.String("order", "", "order id")
+		venue := fs.String("venue", "", "venue name or id")
+		fs.Parse(args[1:])
+
+		if fs.NArg() != 1 || (*orderId == "") == (*venue == "") {
+			noteUsage()
+		}
+
+		note := storage.Note{OrderId: nilIfEmpty(*orderId), Text: fs.Arg(0)}
+		if *orderId != "" {
+			err = storage.CheckOrderId(db, *orderId)
+			if err != nil {
+				return err
+			}
+		}
+		if *venue != "" {
+			venueId, err := storage.ResolveVenueId(db, *venue)
+			if err != nil {
+				return err
+			}
+			note.VenueId = &venueId
+		}
+
+		id, err := storage.AddNote(db, note)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("added note %d\n", id)
+	case "remove":
+		if len(args) != 2 {
+			noteUsage()
+		}
+
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		return storage.DeleteNote(db, id)
+	case "list":
+		notes, err := storage.GetNotes(db)
+		if err != nil {
+			return err
+		}
+
+		for _, n := range *notes {
+			target := "venue " + valueOrAny(n.VenueId)
+			if n.OrderId != nil {
+				target = "order " + *n.OrderId
+			}
+
+			fmt.Printf("%d\t%s\t%s\t%s\n", n.NoteId, n.CreatedAt.Format("2006-01-02"), target, n.Text)
+		}
+	default:
+		noteUsage()
+	}
+
+	return nil
+}
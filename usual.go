@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"frederikhs/wolt/export"
+	"frederikhs/wolt/storage"
+	"math"
+	"os"
+)
+
+func Usual(args []string) error {
+	fs := flag.NewFlagSet("usual", flag.ExitOnError)
+	share := fs.Float64("share", 0.5, "share of the orders at the venue a dish must be in to be part of the usual order")
+	account := fs.String("account", "", "only consider the orders of this account")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Printf("usage: %s usual [-share 0.5] [-account NAME] <VENUE>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	db, err := storage.Connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	venueId, err := storage.ResolveVenueId(db, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	combinations, err := storage.GetItemCombinations(db, storage.Filter{Venue: venueId, Account: *account})
+	if err != nil {
+		return err
+	}
+
+	if len(*combinations) == 0 {
+		return fmt.Errorf("no delivered orders at %s", fs.Arg(0))
+	}
+
+	// the combinations are sorted by the number of orders they were in, and
+	// the most frequent one is the usual order even when below the share
+	var basket []storage.ItemCombination
+	for i, c := range *combinations {
+		if i == 0 || float64(c.Orders) >= *share*float64(c.VenueOrders) {
+			basket = append(basket, c)
+		}
+	}
+
+	first := (*combinations)[0]
+	fmt.Printf("Usual order at %s, from %d orders\n\n", first.VenueName, first.VenueOrders)
+
+	total := 0
+	for _, c := range basket {
+		quantity := int(math.Max(1, math.Round(float64(c.Quantity)/float64(c.Orders))))
+		total += quantity * c.LastPrice
+
+		name := c.ItemName
+		if c.Options != "" {
+			name = fmt.Sprintf("%s (%s)", name, c.Options)
+		}
+
+		fmt.Printf("%3d x %-50s %10s  %s, in %d of %d orders\n",
+			quantity, name, export.Money(c.LastPrice), priceTrend(c.FirstPrice, c.LastPrice), c.Orders, c.VenueOrders)
+	}
+
+	fmt.Printf("\n%-56s %10s\n", "Total at last prices", export.Money(total))
+
+	return nil
+}
+
+// priceTrend describes the change from the first to the last price paid.
+func priceTrend(first, last int) string {
+	if first == last || first == 0 {
+		return "unchanged"
+	}
+
+	return fmt.Sprintf("%+.1f%% since %s", float64(last-first)*100/float64(first), export.Money(first))
+}
@@ -0,0 +1,41 @@
+package wolt
+
+// OrderAdjustment is a row of the order adjustments, like a discount, a
+// promo code or a refund, with the amount in our favour in minor units.
+type OrderAdjustment struct {
+	Id        string `json:"id"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	PromoCode string `json:"promo_code,omitempty"`
+	Amount    int    `json:"amount"`
+}
+
+// ToSimpleAdjustments returns the adjustments of the order, numbered in the
+// order they are listed.
+func (fo *FullOrder) ToSimpleAdjustments() []SimpleAdjustment {
+	var adjustments []SimpleAdjustment
+	for n, a := range fo.OrderAdjustmentRows {
+		adjustments = append(adjustments, SimpleAdjustment{
+			OrderId:      fo.OrderId,
+			RowNumber:    n,
+			AdjustmentId: a.Id,
+			Type:         a.Type,
+			Name:         a.Name,
+			PromoCode:    a.PromoCode,
+			Amount:       a.Amount,
+		})
+	}
+
+	return adjustments
+}
+
+type SimpleAdjustment struct {
+	AccountId    string `json:"account_id" db:"account_id"`
+	OrderId      string `json:"order_id" db:"order_id"`
+	RowNumber    int    `json:"row_number" db:"row_number"`
+	AdjustmentId string `json:"adjustment_id" db:"adjustment_id"`
+	Type         string `json:"type" db:"type"`
+	Name         string `json:"name" db:"name"`
+	PromoCode    string `json:"promo_code" db:"promo_code"`
+	Amount       int    `json:"amount" db:"amount"`
+}
@@ -0,0 +1,127 @@
+package wolt
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ItemChange is an entry of the item change log of an order, made by the
+// venue when an item was out of stock. NewItem is nil when the item was
+// removed.
+type ItemChange struct {
+	Type         string       `json:"type"`
+	OriginalItem ChangedItem  `json:"original_item"`
+	NewItem      *ChangedItem `json:"new_item,omitempty"`
+}
+
+type ChangedItem struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Count     int    `json:"count"`
+	Price     int    `json:"price"`
+	EndAmount int    `json:"end_amount"`
+}
+
+const (
+	ChangeSubstituted     = "substituted"
+	ChangeRemoved         = "removed"
+	ChangeQuantityChanged = "quantity_changed"
+)
+
+// Kind tells whether the item was substituted by another, removed or only
+// changed in quantity.
+func (c ItemChange) Kind() string {
+	switch {
+	case c.NewItem == nil || c.NewItem.Count == 0:
+		return ChangeRemoved
+	case c.NewItem.Id != c.OriginalItem.Id:
+		return ChangeSubstituted
+	default:
+		return ChangeQuantityChanged
+	}
+}
+
+// SubstitutionSettings are the substitutes accepted for an item when it is
+// out of stock.
+type SubstitutionSettings struct {
+	AllowedItems []SubstituteItem `json:"allowed_items"`
+	IsAllowed    bool             `json:"is_allowed"`
+}
+
+type SubstituteItem struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UnmarshalJSON also accepts a substitute given by its id alone.
+func (s *SubstituteItem) UnmarshalJSON(b []byte) error {
+	var id string
+	if json.Unmarshal(b, &id) == nil {
+		s.Id = id
+		return nil
+	}
+
+	type substituteItem SubstituteItem
+	return json.Unmarshal(b, (*substituteItem)(s))
+}
+
+// Names lists the allowed substitutes by name, or by id when unnamed.
+func (s SubstitutionSettings) Names() string {
+	var names []string
+	for _, i := range s.AllowedItems {
+		if i.Name != "" {
+			names = append(names, i.Name)
+		} else {
+			names = append(names, i.Id)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// ToSimpleItemChanges returns the item change log of the order, numbered in
+// the order the changes were made.
+func (fo *FullOrder) ToSimpleItemChanges() []SimpleItemChange {
+	var changes []SimpleItemChange
+	for n, c := range fo.ItemChangeLog {
+		change := SimpleItemChange{
+			OrderId:      fo.OrderId,
+			ChangeNumber: n,
+			Type:         c.Type,
+			Kind:         c.Kind(),
+			ItemId:       c.OriginalItem.Id,
+			ItemName:     c.OriginalItem.Name,
+			Count:        c.OriginalItem.Count,
+			EndAmount:    c.OriginalItem.EndAmount,
+		}
+
+		if c.NewItem != nil {
+			change.NewItemId = &c.NewItem.Id
+			change.NewItemName = &c.NewItem.Name
+			change.NewCount = c.NewItem.Count
+			change.NewEndAmount = c.NewItem.EndAmount
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// SimpleItemChange is a change to an item of an order, with the amounts
+// before and after in minor units.
+type SimpleItemChange struct {
+	AccountId    string  `json:"account_id" db:"account_id"`
+	OrderId      string  `json:"order_id" db:"order_id"`
+	ChangeNumber int     `json:"change_number" db:"change_number"`
+	Type         string  `json:"type" db:"type"`
+	Kind         string  `json:"kind" db:"kind"`
+	ItemId       string  `json:"item_id" db:"item_id"`
+	ItemName     string  `json:"item_name" db:"item_name"`
+	Count        int     `json:"count" db:"count"`
+	EndAmount    int     `json:"end_amount" db:"end_amount"`
+	NewItemId    *string `json:"new_item_id" db:"new_item_id"`
+	NewItemName  *string `json:"new_item_name" db:"new_item_name"`
+	NewCount     int     `json:"new_count" db:"new_count"`
+	NewEndAmount int     `json:"new_end_amount" db:"new_end_amount"`
+}
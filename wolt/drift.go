@@ -0,0 +1,227 @@
+package wolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	DriftUnknown  = "unknown"
+	DriftMismatch = "mismatch"
+	DriftMissing  = "missing"
+)
+
+// Drift is a difference between the orders the api returns and FullOrder,
+// a field FullOrder does not know, a field of another type than expected or
+// an expected field that is missing. Paths name the fields of list elements
+// with [], like items[].price.
+type Drift struct {
+	Kind     string `json:"kind"`
+	Path     string `json:"path"`
+	Expected string `json:"expected,omitempty"`
+	Got      string `json:"got,omitempty"`
+	Orders   int    `json:"orders"`
+	Example  string `json:"example"`
+}
+
+type DriftReport struct {
+	Orders int     `json:"orders"`
+	Drifts []Drift `json:"drifts"`
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// CheckDrift decodes every order strictly against FullOrder and reports each
+// difference once, with the number of orders it was found in.
+func CheckDrift(orders []json.RawMessage) (*DriftReport, error) {
+	report := &DriftReport{Orders: len(orders)}
+	index := map[Drift]int{}
+
+	for _, raw := range orders {
+		var v interface{}
+		err := json.Unmarshal(raw, &v)
+		if err != nil {
+			return nil, err
+		}
+
+		orderId := ""
+		if m, ok := v.(map[string]interface{}); ok {
+			orderId, _ = m["order_id"].(string)
+		}
+
+		seen := map[Drift]bool{}
+		walkDrift("", reflect.TypeOf(FullOrder{}), v, func(d Drift) {
+			if seen[d] {
+				return
+			}
+			seen[d] = true
+
+			i, ok := index[d]
+			if !ok {
+				i = len(report.Drifts)
+				index[d] = i
+				d.Example = orderId
+				report.Drifts = append(report.Drifts, d)
+			}
+			report.Drifts[i].Orders++
+		})
+	}
+
+	sort.SliceStable(report.Drifts, func(i, j int) bool {
+		a, b := report.Drifts[i], report.Drifts[j]
+		if a.Kind != b.Kind {
+			return a.Kind > b.Kind
+		}
+		return a.Path < b.Path
+	})
+
+	return report, nil
+}
+
+func walkDrift(path string, t reflect.Type, v interface{}, found func(Drift)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// null decodes into anything as the zero value, and types decoding
+	// themselves may accept several shapes
+	if v == nil || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	expected := jsonType(t)
+	got := jsonValueType(v)
+	if expected != "" && expected != got && !(expected == "integer" && got == "number") {
+		found(Drift{Kind: DriftMismatch, Path: path, Expected: expected, Got: got})
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object := v.(map[string]interface{})
+		known := map[string]bool{}
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, omitempty := jsonField(f)
+			if name == "" {
+				continue
+			}
+			known[name] = true
+
+			value, ok := object[name]
+			if !ok {
+				if !omitempty {
+					found(Drift{Kind: DriftMissing, Path: join(path, name), Expected: jsonType(f.Type)})
+				}
+				continue
+			}
+
+			walkDrift(join(path, name), f.Type, value, found)
+		}
+
+		for name, value := range object {
+			if !known[name] {
+				found(Drift{Kind: DriftUnknown, Path: join(path, name), Got: jsonValueType(value)})
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for _, e := range v.([]interface{}) {
+			walkDrift(path+"[]", t.Elem(), e, found)
+		}
+	case reflect.Map:
+		for _, e := range v.(map[string]interface{}) {
+			walkDrift(path+"{}", t.Elem(), e, found)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n := v.(float64); n != math.Trunc(n) {
+			found(Drift{Kind: DriftMismatch, Path: path, Expected: expected, Got: "number with fraction"})
+		}
+	}
+}
+
+// jsonField returns the json name of a struct field and whether it may be
+// left out, or an empty name when it is not decoded.
+func jsonField(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = f.Name
+	}
+
+	omitempty := false
+	for _, p := range parts[1:] {
+		if p == "omitempty" {
+			omitempty = true
+		}
+	}
+
+	return name, omitempty
+}
+
+// jsonType names the json type a go type decodes from, empty when it decodes
+// from any.
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+
+	return ""
+}
+
+func jsonValueType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
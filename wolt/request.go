@@ -2,6 +2,7 @@ package wolt
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -32,7 +33,52 @@ func NewClient(token string) *Client {
 	}
 }
 
-func (c *Client) RequestOrders(limit, skip int) (*[]FullOrder, error) {
+// DecodeOrders decodes every order on its own, leaving out the ones that do
+// not decode and returning a problem that skips each of them.
+func DecodeOrders(raw []json.RawMessage) (*[]FullOrder, []ValidationError) {
+	var orders []FullOrder
+	var problems []ValidationError
+	for _, r := range raw {
+		o, problem := DecodeOrder(r)
+		if problem != nil {
+			problems = append(problems, *problem)
+			continue
+		}
+
+		orders = append(orders, o)
+	}
+
+	return &orders, problems
+}
+
+// DecodeOrder decodes an order as the api sends it. An order that does not
+// decode is described by a problem that skips it.
+func DecodeOrder(raw []byte) (FullOrder, *ValidationError) {
+	var o FullOrder
+	err := json.Unmarshal(raw, &o)
+	if err == nil {
+		return o, nil
+	}
+
+	var id struct {
+		OrderId string `json:"order_id"`
+	}
+	json.Unmarshal(raw, &id)
+
+	problem := ValidationError{OrderId: id.OrderId, Field: "order", Problem: fmt.Sprintf("does not decode: %s", err), Skip: true}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		problem.Field = typeErr.Field
+		problem.Problem = fmt.Sprintf("is a json %s rather than %s", typeErr.Value, typeErr.Type)
+	}
+
+	return FullOrder{}, &problem
+}
+
+// RequestRawOrders returns the orders as the api sent them, keeping the
+// fields FullOrder does not know about.
+func (c *Client) RequestRawOrders(limit, skip int) ([]json.RawMessage, error) {
 	endpointUrl := c.constructUrl("/v2/order_details/")
 
 	q := url.Values{}
@@ -45,8 +91,10 @@ func (c *Client) RequestOrders(limit, skip int) (*[]FullOrder, error) {
 		return nil, err
 	}
 
-	var orders []FullOrder
-	return &orders, c.handleRequestResponse(req, &orders)
+	var orders []json.RawMessage
+	err = c.handleRequestResponse(req, &orders)
+
+	return orders, err
 }
 
 func (c *Client) constructUrl(path string) *url.URL {
@@ -5,11 +5,12 @@ import (
 )
 
 type SimpleOrder struct {
+	AccountId                 string     `json:"account_id" db:"account_id"`
 	OrderId                   string     `json:"order_id" db:"order_id"`
 	ClientPreEstimate         string     `json:"client_pre_estimate" db:"client_pre_estimate"`
 	DeliveryStreet            string     `json:"delivery_street" db:"delivery_street"`
-	DeliveryCoordinateX       float64    `json:"delivery_coordinate_x" db:"delivery_coordinate_x"`
-	DeliveryCoordinateY       float64    `json:"delivery_coordinate_y" db:"delivery_coordinate_y"`
+	DeliveryCoordinateX       *float64   `json:"delivery_coordinate_x" db:"delivery_coordinate_x"`
+	DeliveryCoordinateY       *float64   `json:"delivery_coordinate_y" db:"delivery_coordinate_y"`
 	DeliveryDistance          int        `json:"delivery_distance" db:"delivery_distance"`
 	DeliveryEta               *time.Time `json:"delivery_eta" db:"delivery_eta"`
 	DeliveryMethod            string     `json:"delivery_method" db:"delivery_method"`
@@ -27,6 +28,18 @@ type SimpleOrder struct {
 	TotalPrice                int        `json:"total_price" db:"total_price"`
 	VenueId                   string     `json:"venue_id" db:"venue_id"`
 	PreorderTime              *time.Time `json:"preorder_time" db:"preorder_time"`
+	OrderNumber               string     `json:"order_number" db:"order_number"`
+	DeliveryAlias             string     `json:"delivery_alias" db:"delivery_alias"`
+	Currency                  string     `json:"currency" db:"currency"`
+	Tip                       int        `json:"tip" db:"tip"`
+	IsHostPaying              bool       `json:"is_host_paying" db:"is_host_paying"`
+	DeliveryPriceShare        int        `json:"delivery_price_share" db:"delivery_price_share"`
+	TipShare                  int        `json:"tip_share" db:"tip_share"`
+	TotalPriceShare           int        `json:"total_price_share" db:"total_price_share"`
+	PaymentMethodId           string     `json:"payment_method_id" db:"payment_method_id"`
+	PaymentProvider           string     `json:"payment_provider" db:"payment_provider"`
+	PaymentType               string     `json:"payment_type" db:"payment_type"`
+	PaymentName               string     `json:"payment_name" db:"payment_name"`
 }
 
 func UnixOrNil(i int64) *time.Time {
@@ -39,18 +52,12 @@ func UnixOrNil(i int64) *time.Time {
 	return &t
 }
 
+// ToSimpleOrder leaves the delivery coordinates nil for takeaway orders and
+// when they are missing.
 func (fo *FullOrder) ToSimpleOrder() SimpleOrder {
-	var dCoordX float64
-	var dCoordY float64
-
-	if fo.DeliveryMethod == "takeaway" {
-		dCoordX = 0
-		dCoordY = 0
-	} else {
-		if len(fo.DeliveryLocation.Coordinates.Coordinates) == 2 {
-			dCoordX = fo.DeliveryLocation.Coordinates.Coordinates[0]
-			dCoordY = fo.DeliveryLocation.Coordinates.Coordinates[1]
-		}
+	var dCoordX, dCoordY *float64
+	if fo.DeliveryMethod != "takeaway" {
+		dCoordX, dCoordY = coordinates(fo.DeliveryLocation.Coordinates.Coordinates)
 	}
 
 	return SimpleOrder{
@@ -76,27 +83,127 @@ func (fo *FullOrder) ToSimpleOrder() SimpleOrder {
 		TotalPrice:                fo.TotalPrice,
 		VenueId:                   fo.VenueId,
 		PreorderTime:              UnixOrNil(fo.PreorderTime.Date),
+		OrderNumber:               fo.OrderNumber,
+		DeliveryAlias:             fo.DeliveryLocation.Alias,
+		Currency:                  fo.Currency,
+		Tip:                       fo.Tip,
+		IsHostPaying:              fo.IsHostPaying,
+		DeliveryPriceShare:        fo.DeliveryPriceShare,
+		TipShare:                  fo.TipShare,
+		TotalPriceShare:           fo.TotalPriceShare,
+		PaymentMethodId:           fo.PaymentMethod.Id,
+		PaymentProvider:           fo.PaymentMethod.Provider,
+		PaymentType:               fo.PaymentMethod.Type,
+		PaymentName:               fo.PaymentName,
 	}
 }
 
 func (fo *FullOrder) ToSimpleVenue() SimpleVenue {
+	x, y := coordinates(fo.VenueCoordinates)
+
 	return SimpleVenue{
-		VenueId:          fo.VenueId,
-		VenueName:        fo.VenueName,
-		VenueProductLine: fo.VenueProductLine,
-		VenueCoordinateX: fo.VenueCoordinates[0],
-		VenueCoordinateY: fo.VenueCoordinates[1],
-		VenueUrl:         fo.VenueUrl,
+		VenueId:           fo.VenueId,
+		VenueName:         fo.VenueName,
+		VenueAddress:      fo.VenueAddress,
+		VenueFullAddress:  fo.VenueFullAddress,
+		VenueCountry:      fo.VenueCountry,
+		VenuePhone:        fo.VenuePhone,
+		VenueTimezone:     fo.VenueTimezone,
+		VenueProductLine:  fo.VenueProductLine,
+		VenueCoordinateX:  x,
+		VenueCoordinateY:  y,
+		VenueUrl:          fo.VenueUrl,
+		MainImage:         fo.MainImage,
+		MainImageBlurhash: fo.MainImageBlurhash,
+		ListImage:         fo.ListImage,
+		ListImageBlurhash: fo.ListImageBlurhash,
+	}
+}
+
+func (fo *FullOrder) ToSimpleItems() []SimpleItem {
+	var items []SimpleItem
+	for _, i := range fo.Items {
+		items = append(items, SimpleItem{
+			OrderId:             fo.OrderId,
+			RowNumber:           i.RowNumber,
+			ItemId:              i.Id,
+			ItemName:            i.Name,
+			Count:               i.Count,
+			Price:               i.Price,
+			EndAmount:           i.EndAmount,
+			SubstitutionAllowed: i.SubstitutionSettings.IsAllowed,
+			Substitutes:         i.SubstitutionSettings.Names(),
+		})
 	}
+
+	return items
+}
+
+// ToSimpleItemOptions returns the chosen option values of every item, like
+// toppings or sizes, with the price of a single one.
+func (fo *FullOrder) ToSimpleItemOptions() []SimpleItemOption {
+	var options []SimpleItemOption
+	for _, i := range fo.Items {
+		for _, o := range i.Options {
+			for _, v := range o.Values {
+				options = append(options, SimpleItemOption{
+					OrderId:    fo.OrderId,
+					RowNumber:  i.RowNumber,
+					OptionId:   o.Id,
+					OptionName: o.Name,
+					ValueId:    v.Id,
+					ValueName:  v.Name,
+					Count:      v.Count,
+					Price:      v.Price,
+				})
+			}
+		}
+	}
+
+	return options
+}
+
+type SimpleItemOption struct {
+	AccountId  string `json:"account_id" db:"account_id"`
+	OrderId    string `json:"order_id" db:"order_id"`
+	RowNumber  int    `json:"row_number" db:"row_number"`
+	OptionId   string `json:"option_id" db:"option_id"`
+	OptionName string `json:"option_name" db:"option_name"`
+	ValueId    string `json:"value_id" db:"value_id"`
+	ValueName  string `json:"value_name" db:"value_name"`
+	Count      int    `json:"count" db:"count"`
+	Price      int    `json:"price" db:"price"`
+}
+
+type SimpleItem struct {
+	AccountId           string `json:"account_id" db:"account_id"`
+	OrderId             string `json:"order_id" db:"order_id"`
+	RowNumber           int    `json:"row_number" db:"row_number"`
+	ItemId              string `json:"item_id" db:"item_id"`
+	ItemName            string `json:"item_name" db:"item_name"`
+	Count               int    `json:"count" db:"count"`
+	Price               int    `json:"price" db:"price"`
+	EndAmount           int    `json:"end_amount" db:"end_amount"`
+	SubstitutionAllowed bool   `json:"substitution_allowed" db:"substitution_allowed"`
+	Substitutes         string `json:"substitutes" db:"substitutes"`
 }
 
 type SimpleVenue struct {
-	VenueId          string  `json:"venue_id" db:"venue_id"`
-	VenueName        string  `json:"venue_name" db:"venue_name"`
-	VenueProductLine string  `json:"venue_product_line" db:"venue_product_line"`
-	VenueCoordinateX float64 `json:"venue_coordinate_x" db:"venue_coordinate_x"`
-	VenueCoordinateY float64 `json:"venue_coordinate_y" db:"venue_coordinate_y"`
-	VenueUrl         string  `json:"venue_url" db:"venue_url"`
+	VenueId           string   `json:"venue_id" db:"venue_id"`
+	VenueName         string   `json:"venue_name" db:"venue_name"`
+	VenueAddress      string   `json:"venue_address" db:"venue_address"`
+	VenueFullAddress  string   `json:"venue_full_address" db:"venue_full_address"`
+	VenueCountry      string   `json:"venue_country" db:"venue_country"`
+	VenuePhone        string   `json:"venue_phone" db:"venue_phone"`
+	VenueTimezone     string   `json:"venue_timezone" db:"venue_timezone"`
+	VenueProductLine  string   `json:"venue_product_line" db:"venue_product_line"`
+	VenueCoordinateX  *float64 `json:"venue_coordinate_x" db:"venue_coordinate_x"`
+	VenueCoordinateY  *float64 `json:"venue_coordinate_y" db:"venue_coordinate_y"`
+	VenueUrl          string   `json:"venue_url" db:"venue_url"`
+	MainImage         string   `json:"main_image" db:"main_image"`
+	MainImageBlurhash string   `json:"main_image_blurhash" db:"main_image_blurhash"`
+	ListImage         string   `json:"list_image" db:"list_image"`
+	ListImageBlurhash string   `json:"list_image_blurhash" db:"list_image_blurhash"`
 }
 
 type FullOrder struct {
@@ -130,10 +237,10 @@ type FullOrder struct {
 	DeliveryTime          struct {
 		Date int64 `json:"$date"`
 	} `json:"delivery_time"`
-	DriverType      string        `json:"driver_type"`
-	IsHostPaying    bool          `json:"is_host_paying"`
-	IsMarketplaceV2 bool          `json:"is_marketplace_v2"`
-	ItemChangeLog   []interface{} `json:"item_change_log"`
+	DriverType      string       `json:"driver_type"`
+	IsHostPaying    bool         `json:"is_host_paying"`
+	IsMarketplaceV2 bool         `json:"is_marketplace_v2"`
+	ItemChangeLog   []ItemChange `json:"item_change_log"`
 	Items           []struct {
 		Count     int    `json:"count"`
 		EndAmount int    `json:"end_amount"`
@@ -150,23 +257,20 @@ type FullOrder struct {
 				Price int    `json:"price"`
 			} `json:"values"`
 		} `json:"options"`
-		Price                int  `json:"price"`
-		RowNumber            int  `json:"row_number"`
-		SkipOnRefill         bool `json:"skip_on_refill"`
-		SubstitutionSettings struct {
-			AllowedItems []interface{} `json:"allowed_items"`
-			IsAllowed    bool          `json:"is_allowed"`
-		} `json:"substitution_settings,omitempty"`
+		Price                int                  `json:"price"`
+		RowNumber            int                  `json:"row_number"`
+		SkipOnRefill         bool                 `json:"skip_on_refill"`
+		SubstitutionSettings SubstitutionSettings `json:"substitution_settings,omitempty"`
 	} `json:"items"`
-	ItemsPrice          int           `json:"items_price"`
-	ListImage           string        `json:"list_image"`
-	ListImageBlurhash   string        `json:"list_image_blurhash"`
-	MainImage           string        `json:"main_image"`
-	MainImageBlurhash   string        `json:"main_image_blurhash"`
-	OrderAdjustmentRows []interface{} `json:"order_adjustment_rows"`
-	OrderId             string        `json:"order_id"`
-	OrderNumber         string        `json:"order_number"`
-	PaymentAmount       int           `json:"payment_amount"`
+	ItemsPrice          int               `json:"items_price"`
+	ListImage           string            `json:"list_image"`
+	ListImageBlurhash   string            `json:"list_image_blurhash"`
+	MainImage           string            `json:"main_image"`
+	MainImageBlurhash   string            `json:"main_image_blurhash"`
+	OrderAdjustmentRows []OrderAdjustment `json:"order_adjustment_rows"`
+	OrderId             string            `json:"order_id"`
+	OrderNumber         string            `json:"order_number"`
+	PaymentAmount       int               `json:"payment_amount"`
 	PaymentMethod       struct {
 		Id       string `json:"id"`
 		Provider string `json:"provider"`
@@ -0,0 +1,108 @@
+package wolt
+
+import "fmt"
+
+// ValidationError is a problem with a field of an order. Orders with a
+// problem that Skip are not stored, the others are stored with the field
+// left empty.
+type ValidationError struct {
+	OrderId string `json:"order_id"`
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+	Skip    bool   `json:"skip"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("order %s: %s %s", e.OrderId, e.Field, e.Problem)
+}
+
+// Validate checks that the order has what storing it relies on.
+func (fo *FullOrder) Validate() []ValidationError {
+	var errs []ValidationError
+	problem := func(field, p string, skip bool) {
+		errs = append(errs, ValidationError{OrderId: fo.OrderId, Field: field, Problem: p, Skip: skip})
+	}
+
+	if fo.OrderId == "" {
+		problem("order_id", "is missing", true)
+	}
+
+	if fo.VenueId == "" {
+		problem("venue_id", "is missing", true)
+	}
+
+	rows := map[int]bool{}
+	for _, i := range fo.Items {
+		if rows[i.RowNumber] {
+			problem("items", fmt.Sprintf("repeat row number %d", i.RowNumber), true)
+		}
+		rows[i.RowNumber] = true
+	}
+
+	// Every period the orders are reported by is taken from the payment
+	// time, an order without one belongs to none of them.
+	if fo.PaymentTime.Date == 0 {
+		problem("payment_time", "is missing", true)
+	}
+
+	if fo.Status == "" {
+		problem("status", "is missing", false)
+	}
+
+	if len(fo.VenueCoordinates) != 2 {
+		problem("venue_coordinates", fmt.Sprintf("has %d coordinates instead of 2", len(fo.VenueCoordinates)), false)
+	}
+
+	if fo.DeliveryMethod != "takeaway" && len(fo.DeliveryLocation.Coordinates.Coordinates) != 2 {
+		problem("delivery_location.coordinates", fmt.Sprintf("has %d coordinates instead of 2", len(fo.DeliveryLocation.Coordinates.Coordinates)), false)
+	}
+
+	return errs
+}
+
+// Skipped tells whether any of the problems keeps the order from being
+// stored.
+func Skipped(errs []ValidationError) bool {
+	for _, e := range errs {
+		if e.Skip {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Storable returns the orders of an account that are stored, leaving out
+// the ones with a problem that skips them and the repeats of an order, along
+// with the problems of every order.
+func Storable(orders []FullOrder) ([]FullOrder, []ValidationError) {
+	var storable []FullOrder
+	var problems []ValidationError
+	seen := map[string]bool{}
+	for _, o := range orders {
+		errs := o.Validate()
+		if seen[o.OrderId] {
+			errs = append(errs, ValidationError{OrderId: o.OrderId, Field: "order_id", Problem: "is repeated", Skip: true})
+		}
+
+		problems = append(problems, errs...)
+		if Skipped(errs) {
+			continue
+		}
+		seen[o.OrderId] = true
+
+		storable = append(storable, o)
+	}
+
+	return storable, problems
+}
+
+// coordinates returns the x and y of a pair of coordinates, or nil when
+// there is no pair.
+func coordinates(c []float64) (*float64, *float64) {
+	if len(c) != 2 {
+		return nil, nil
+	}
+
+	return &c[0], &c[1]
+}